@@ -0,0 +1,406 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+const (
+	backupDBName     = "sqlite.db"
+	backupConfigName = "config.yaml"
+)
+
+var commands = map[string]func(args []string) error{
+	"backup":  backupCommand,
+	"restore": restoreCommand,
+	"export":  exportCommand,
+	"import":  importCommand,
+	"sync":    syncCommand,
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command] [command flags]\n\n", os.Args[0])
+	fmt.Fprintln(flag.CommandLine.Output(), "Commands:")
+	fmt.Fprintln(flag.CommandLine.Output(), "  backup   备份 sqlite 数据库与配置文件")
+	fmt.Fprintln(flag.CommandLine.Output(), "  restore  从备份文件恢复 sqlite 数据库与配置文件")
+	fmt.Fprintln(flag.CommandLine.Output(), "  export   导出服务器、分组、服务监控、报警规则、计划任务、通知、DDNS、内网穿透配置")
+	fmt.Fprintln(flag.CommandLine.Output(), "  import   导入 export 导出的配置")
+	fmt.Fprintln(flag.CommandLine.Output(), "  sync     将声明式配置中的服务监控、报警规则、通知组、计划任务同步到运行中的面板")
+	fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// runCommand 执行子命令
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		flag.Usage()
+		return fmt.Errorf("unknown command: %s", name)
+	}
+	return command(args)
+}
+
+// initConfig 子命令使用的配置初始化
+func initConfig() {
+	singleton.InitFrontendTemplates()
+	singleton.InitConfigFromPath(dashboardCliParam.ConfigFile)
+	singleton.InitTimezoneAndCache()
+}
+
+// initDB 子命令使用的配置与数据库初始化
+func initDB() {
+	initConfig()
+	singleton.InitDBFromPath(dashboardCliParam.DatebaseLocation)
+}
+
+// backupCommand 在线生成一致的 sqlite 数据库快照，与配置文件一起打包
+func backupCommand(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", fmt.Sprintf("nezha-backup-%s.tar.gz", time.Now().Format("20060102150405")), "备份文件路径")
+	fs.Parse(args)
+
+	if _, err := os.Stat(dashboardCliParam.ConfigFile); err != nil {
+		return err
+	}
+	initConfig()
+	if singleton.Conf.DBDriver != model.DBDriverSQLite {
+		return fmt.Errorf("backup only supports sqlite, use export or the backup tool of %s instead", singleton.Conf.DBDriver)
+	}
+	if _, err := os.Stat(dashboardCliParam.DatebaseLocation); err != nil {
+		return err
+	}
+	// 备份不应修改数据库，不执行表结构迁移
+	singleton.OpenDBFromPath(dashboardCliParam.DatebaseLocation)
+
+	tmpDir, err := os.MkdirTemp("", "nezha-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, backupDBName)
+	// VACUUM INTO 在不阻塞写入的情况下生成一致的快照
+	if err := singleton.DB.Exec("VACUUM INTO ?", snapshot).Error; err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(*output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for name, path := range map[string]string{
+		backupDBName:     snapshot,
+		backupConfigName: dashboardCliParam.ConfigFile,
+	} {
+		if err := addFileToTar(tw, name, path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	fmt.Println("NEZHA>> Backup saved to", *output)
+	return nil
+}
+
+// restoreCommand 将备份文件中的数据库与配置文件恢复到 -db 与 -c 指定的路径，恢复前需停止面板
+func restoreCommand(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	input := fs.String("i", "", "备份文件路径")
+	force := fs.Bool("f", false, "覆盖已存在的数据库与配置文件")
+	fs.Parse(args)
+	if *input == "" {
+		return errors.New("backup file is required")
+	}
+
+	targets := map[string]string{
+		backupDBName:     dashboardCliParam.DatebaseLocation,
+		backupConfigName: dashboardCliParam.ConfigFile,
+	}
+	if !*force {
+		for _, path := range targets {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, use -f to overwrite", path)
+			}
+		}
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+	restored := make(map[string]string)
+	defer func() {
+		for _, tmp := range restored {
+			os.Remove(tmp)
+		}
+	}()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		target, ok := targets[hdr.Name]
+		if !ok {
+			continue
+		}
+		tmp, err := writeTempFile(target, tr)
+		if err != nil {
+			return err
+		}
+		restored[hdr.Name] = tmp
+	}
+	for name := range targets {
+		if _, ok := restored[name]; !ok {
+			return fmt.Errorf("%s not found in backup", name)
+		}
+	}
+
+	// 清理旧数据库遗留的日志文件，避免其被应用到恢复的数据库上
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dashboardCliParam.DatebaseLocation + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for name, tmp := range restored {
+		if err := os.Rename(tmp, targets[name]); err != nil {
+			return err
+		}
+		delete(restored, name)
+	}
+	fmt.Println("NEZHA>> Restored from", *input)
+	return nil
+}
+
+// exportCommand 导出全部配置项
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "-", "导出文件路径，- 为标准输出")
+	format := fs.String("format", "", "导出格式 json 或 yaml，默认按文件扩展名判断")
+	secrets := fs.Bool("secrets", false, "同时导出心跳监控的上报密钥，导出文件需妥善保管")
+	fs.Parse(args)
+
+	initDB()
+	bundle, err := singleton.ExportConfig(singleton.DB, *secrets)
+	if err != nil {
+		return err
+	}
+	data, err := model.MarshalConfigBundle(bundle, bundleFormat(*format, *output))
+	if err != nil {
+		return err
+	}
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0600)
+}
+
+// importCommand 导入配置项，导入后需重启面板生效
+func importCommand(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	input := fs.String("i", "", "导入文件路径，- 为标准输入")
+	format := fs.String("format", "", "导入格式 json 或 yaml，默认按文件扩展名判断")
+	fs.Parse(args)
+	if *input == "" {
+		return errors.New("import file is required")
+	}
+
+	var data []byte
+	var err error
+	if *input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return err
+	}
+	bundle, err := model.UnmarshalConfigBundle(data, bundleFormat(*format, *input))
+	if err != nil {
+		return err
+	}
+
+	initDB()
+	if err := singleton.ImportConfig(singleton.DB, bundle); err != nil {
+		return err
+	}
+	fmt.Println("NEZHA>> Imported, restart the dashboard to apply")
+	return nil
+}
+
+// syncCommand 通过面板接口对比声明式配置与当前配置，指定 -apply 时执行变更
+func syncCommand(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	input := fs.String("f", "", "声明式配置文件路径，- 为标准输入")
+	format := fs.String("format", "", "配置格式 json 或 yaml，默认按文件扩展名判断")
+	server := fs.String("server", "http://127.0.0.1:8008", "面板地址")
+	token := fs.String("token", os.Getenv("NZ_TOKEN"), "API Token，默认读取环境变量 NZ_TOKEN")
+	username := fs.String("u", "", "未指定 Token 时用于登录的用户名")
+	password := fs.String("p", "", "未指定 Token 时用于登录的密码")
+	apply := fs.Bool("apply", false, "执行变更，否则仅输出变更计划")
+	fs.Parse(args)
+	if *input == "" {
+		return errors.New("config file is required")
+	}
+
+	var data []byte
+	var err error
+	if *input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return err
+	}
+	contentType := "application/json"
+	if bundleFormat(*format, *input) == model.ConfigBundleFormatYAML {
+		contentType = "application/yaml"
+	}
+
+	baseURL := strings.TrimSuffix(*server, "/") + "/api/v1"
+	if *token == "" {
+		if *username == "" {
+			return errors.New("token or username is required")
+		}
+		body, err := utils.Json.Marshal(model.LoginRequest{Username: *username, Password: *password})
+		if err != nil {
+			return err
+		}
+		var login model.CommonResponse[model.LoginResponse]
+		if err := postAPI(baseURL+"/login", "", "application/json", body, &login); err != nil {
+			return err
+		}
+		*token = login.Data.Token
+	}
+
+	endpoint := baseURL + "/config-sync/plan"
+	if *apply {
+		endpoint = baseURL + "/config-sync/apply"
+	}
+	var resp model.CommonResponse[model.ConfigSyncPlan]
+	if err := postAPI(endpoint, *token, contentType, data, &resp); err != nil {
+		return err
+	}
+	for _, change := range resp.Data.Changes {
+		fmt.Println(change)
+	}
+	switch {
+	case len(resp.Data.Changes) == 0:
+		fmt.Println("NEZHA>> No changes")
+	case resp.Data.Applied:
+		fmt.Printf("NEZHA>> Applied %d changes\n", len(resp.Data.Changes))
+	default:
+		fmt.Printf("NEZHA>> %d changes, run with -apply to apply\n", len(resp.Data.Changes))
+	}
+	return nil
+}
+
+// postAPI 请求面板接口并解析 CommonResponse
+func postAPI[T any](url, token, contentType string, body []byte, resp *model.CommonResponse[T]) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := utils.Json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("%s: %s", r.Status, data)
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s: %s", r.Status, resp.Error)
+	}
+	return nil
+}
+
+// bundleFormat 未指定格式时按文件扩展名判断，默认为 json
+func bundleFormat(format, path string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return model.ConfigBundleFormatYAML
+	}
+	return model.ConfigBundleFormatJSON
+}
+
+func addFileToTar(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// writeTempFile 将内容写入目标路径所在目录下的临时文件
+func writeTempFile(target string, r io.Reader) (string, error) {
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(target)+".restore-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// Get agent config of server
+// @Summary Get agent config of server
+// @Security BearerAuth
+// @Schemes
+// @Description Get the agent config of server, the effective config merged with server groups and the version applied by agent
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentConfigResponse]
+// @Router /server/{id}/agent-config [get]
+func getServerAgentConfig(c *gin.Context) (*model.AgentConfigResponse, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	effective, err := singleton.EffectiveAgentConfig(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.AgentConfigResponse{
+		Effective: effective,
+		Version:   effective.Version(),
+	}
+	singleton.ServerLock.RLock()
+	if server, ok := singleton.ServerList[id]; ok {
+		resp.Config = server.AgentConfig
+		resp.AppliedVersion = server.AgentConfigVersion
+	}
+	singleton.ServerLock.RUnlock()
+	return resp, nil
+}
+
+// Update agent config of server
+// @Summary Update agent config of server
+// @Security BearerAuth
+// @Schemes
+// @Description Update the agent config of server and push the effective config to agent, unset fields keep the agent local config
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Server ID"
+// @param request body model.AgentConfig true "Agent Config"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /server/{id}/agent-config [patch]
+func updateServerAgentConfig(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := bindAgentConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var s model.Server
+	if err := singleton.DB.First(&s, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("server id %d does not exist", id)
+	}
+	s.AgentConfig = config
+	if err := singleton.DB.Save(&s).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.ServerLock.Lock()
+	if server, ok := singleton.ServerList[id]; ok {
+		server.AgentConfig = config
+	}
+	singleton.ServerLock.Unlock()
+
+	singleton.PushAgentConfigs([]uint64{id})
+	return nil, nil
+}
+
+// Update agent config of server group
+// @Summary Update agent config of server group
+// @Security BearerAuth
+// @Schemes
+// @Description Update the agent config of server group and push the effective config to agents in the group
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Server Group ID"
+// @param request body model.AgentConfig true "Agent Config"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /server-group/{id}/agent-config [patch]
+func updateServerGroupAgentConfig(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := bindAgentConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var sg model.ServerGroup
+	if err := singleton.DB.First(&sg, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("group id %d does not exist", id)
+	}
+	sg.AgentConfig = config
+	if err := singleton.DB.Save(&sg).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	servers, err := singleton.ServerGroupMembers([]uint64{id})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	singleton.PushAgentConfigs(servers)
+	return nil, nil
+}
+
+func bindAgentConfig(c *gin.Context) (*model.AgentConfig, error) {
+	var config model.AgentConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, singleton.Localizer.ErrorT("invalid agent config: %v", err)
+	}
+	if config.IsEmpty() {
+		return nil, nil
+	}
+	return &config, nil
+}
@@ -142,6 +142,7 @@ func batchDeleteAlertRule(c *gin.Context) (any, error) {
 	if err := singleton.DB.Unscoped().Delete(&model.AlertRule{}, "id in (?)", ar).Error; err != nil {
 		return nil, newGormError("%v", err)
 	}
+	singleton.DB.Unscoped().Delete(&model.AlertEvent{}, "alert_rule_id in (?)", ar)
 
 	singleton.OnDeleteAlert(ar)
 	return nil, nil
@@ -150,6 +151,12 @@ func batchDeleteAlertRule(c *gin.Context) (any, error) {
 func validateRule(r *model.AlertRule) error {
 	if len(r.Rules) > 0 {
 		for _, rule := range r.Rules {
+			if rule.Type == "custom_metric" && rule.Metric == "" {
+				return singleton.Localizer.ErrorT("metric is required for custom_metric rules")
+			}
+			if rule.Type == "container_restart" && rule.Duration > model.MaxContainerRestartDuration {
+				return singleton.Localizer.ErrorT("duration of container_restart rules must be at most %d", model.MaxContainerRestartDuration)
+			}
 			if !rule.IsTransferDurationRule() {
 				if rule.Duration < 3 {
 					return singleton.Localizer.ErrorT("duration need to be at least 3")
@@ -0,0 +1,487 @@
+package controller
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// cronParser 与 singleton.Cron 相同的计划任务表达式解析器
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// configSync 一次声明式配置同步，文档中未出现的分类不做任何变更，出现的分类中数据库有而文档没有的配置项将被删除
+type configSync struct {
+	plan model.ConfigSyncPlan
+
+	services           []*model.Service
+	deletedServices    []uint64
+	alertRules         []*model.AlertRule
+	deletedAlertRules  []uint64
+	groups             []*model.NotificationGroup
+	groupNotifications map[uint64][]uint64
+	deletedGroups      []uint64
+	crons              []*model.Cron
+	deletedCrons       []uint64
+}
+
+// Plan config sync
+// @Summary Plan config sync
+// @Security BearerAuth
+// @Schemes
+// @Description Diff a declarative document (JSON, or YAML with a yaml Content-Type) of services, alert rules, notification groups and crons against the database without applying it
+// @Tags auth required
+// @Accept json
+// @param request body model.ConfigBundle true "Declarative document"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ConfigSyncPlan]
+// @Router /config-sync/plan [post]
+func planConfigSync(c *gin.Context) (*model.ConfigSyncPlan, error) {
+	doc, err := bindConfigDocument(c)
+	if err != nil {
+		return nil, err
+	}
+	var s configSync
+	if err := s.diff(singleton.DB, doc); err != nil {
+		return nil, err
+	}
+	return &s.plan, nil
+}
+
+// Apply config sync
+// @Summary Apply config sync
+// @Security BearerAuth
+// @Schemes
+// @Description Apply the creates, updates and deletes of a declarative document atomically and reload the affected services, alert rules, notification groups and crons
+// @Tags auth required
+// @Accept json
+// @param request body model.ConfigBundle true "Declarative document"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ConfigSyncPlan]
+// @Router /config-sync/apply [post]
+func applyConfigSync(c *gin.Context) (*model.ConfigSyncPlan, error) {
+	doc, err := bindConfigDocument(c)
+	if err != nil {
+		return nil, err
+	}
+	var s configSync
+	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.diff(tx, doc); err != nil {
+			return err
+		}
+		return s.apply(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reload()
+	s.plan.Applied = true
+	return &s.plan, nil
+}
+
+func bindConfigDocument(c *gin.Context) (*model.ConfigBundle, error) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	format := model.ConfigBundleFormatJSON
+	if strings.Contains(c.ContentType(), "yaml") {
+		format = model.ConfigBundleFormatYAML
+	}
+	return model.UnmarshalConfigBundle(data, format)
+}
+
+// diff 校验文档中的配置项并与数据库对比生成变更计划
+func (s *configSync) diff(db *gorm.DB, doc *model.ConfigBundle) error {
+	s.plan.Changes = []model.ConfigSyncChange{}
+	s.groupNotifications = make(map[uint64][]uint64)
+
+	if doc.NotificationGroups != nil {
+		if err := s.diffNotificationGroups(db, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Crons != nil {
+		if err := s.diffCrons(db, doc.Crons); err != nil {
+			return err
+		}
+	}
+	if doc.Services != nil {
+		if err := s.diffServices(db, doc.Services); err != nil {
+			return err
+		}
+	}
+	if doc.AlertRules != nil {
+		if err := s.diffAlertRules(db, doc.AlertRules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *configSync) diffServices(db *gorm.DB, desired []*model.Service) error {
+	var current []*model.Service
+	if err := db.Find(&current).Error; err != nil {
+		return newGormError("%v", err)
+	}
+	currentMap := make(map[uint64]*model.Service, len(current))
+	for _, m := range current {
+		currentMap[m.ID] = m
+	}
+
+	seen := make(map[uint64]bool, len(desired))
+	for _, m := range desired {
+		if err := checkConfigSyncID(model.ConfigSyncKindService, m.ID, m.Name, seen); err != nil {
+			return err
+		}
+		var mf model.ServiceForm
+		if err := copier.Copy(&mf, m); err != nil {
+			return err
+		}
+		if err := validateServiceForm(&mf); err != nil {
+			return singleton.Localizer.ErrorT("service %d: %v", m.ID, err)
+		}
+		if err := copier.Copy(m, &mf); err != nil {
+			return err
+		}
+		m.Target = strings.TrimSpace(m.Target)
+
+		old, ok := currentMap[m.ID]
+		if ok && m.HeartbeatToken == "" {
+			m.HeartbeatToken = old.HeartbeatToken
+		}
+		if err := setHeartbeatToken(m, false); err != nil {
+			return err
+		}
+		changed, err := s.addChange(model.ConfigSyncKindService, m.ID, m.Name, old, m, ok)
+		if err != nil {
+			return err
+		}
+		if changed {
+			s.services = append(s.services, m)
+		}
+	}
+	for _, m := range current {
+		if !seen[m.ID] {
+			s.deletedServices = append(s.deletedServices, m.ID)
+			s.addDelete(model.ConfigSyncKindService, m.ID, m.Name)
+		}
+	}
+	return nil
+}
+
+func (s *configSync) diffAlertRules(db *gorm.DB, desired []*model.AlertRule) error {
+	var current []*model.AlertRule
+	if err := db.Find(&current).Error; err != nil {
+		return newGormError("%v", err)
+	}
+	currentMap := make(map[uint64]*model.AlertRule, len(current))
+	for _, r := range current {
+		currentMap[r.ID] = r
+	}
+
+	seen := make(map[uint64]bool, len(desired))
+	for _, r := range desired {
+		if err := checkConfigSyncID(model.ConfigSyncKindAlertRule, r.ID, r.Name, seen); err != nil {
+			return err
+		}
+		if err := validateRule(r); err != nil {
+			return singleton.Localizer.ErrorT("alert rule %d: %v", r.ID, err)
+		}
+		old, ok := currentMap[r.ID]
+		changed, err := s.addChange(model.ConfigSyncKindAlertRule, r.ID, r.Name, old, r, ok)
+		if err != nil {
+			return err
+		}
+		if changed {
+			s.alertRules = append(s.alertRules, r)
+		}
+	}
+	for _, r := range current {
+		if !seen[r.ID] {
+			s.deletedAlertRules = append(s.deletedAlertRules, r.ID)
+			s.addDelete(model.ConfigSyncKindAlertRule, r.ID, r.Name)
+		}
+	}
+	return nil
+}
+
+func (s *configSync) diffCrons(db *gorm.DB, desired []*model.Cron) error {
+	var current []*model.Cron
+	if err := db.Find(&current).Error; err != nil {
+		return newGormError("%v", err)
+	}
+	currentMap := make(map[uint64]*model.Cron, len(current))
+	for _, cr := range current {
+		currentMap[cr.ID] = cr
+	}
+
+	seen := make(map[uint64]bool, len(desired))
+	for _, cr := range desired {
+		if err := checkConfigSyncID(model.ConfigSyncKindCron, cr.ID, cr.Name, seen); err != nil {
+			return err
+		}
+		if cr.TaskType == model.CronTypeCronTask {
+			if cr.Cover == model.CronCoverAlertTrigger {
+				return singleton.Localizer.ErrorT("scheduled tasks cannot be triggered by alarms")
+			}
+			if _, err := cronParser.Parse(cr.Scheduler); err != nil {
+				return singleton.Localizer.ErrorT("cron %d: %v", cr.ID, err)
+			}
+		}
+		// 执行状态不由声明式配置管理
+		cr.CronJobID = 0
+		old, ok := currentMap[cr.ID]
+		if ok {
+			cr.LastExecutedAt, cr.LastResult = old.LastExecutedAt, old.LastResult
+		}
+		changed, err := s.addChange(model.ConfigSyncKindCron, cr.ID, cr.Name, old, cr, ok)
+		if err != nil {
+			return err
+		}
+		if changed {
+			s.crons = append(s.crons, cr)
+		}
+	}
+	for _, cr := range current {
+		if !seen[cr.ID] {
+			s.deletedCrons = append(s.deletedCrons, cr.ID)
+			s.addDelete(model.ConfigSyncKindCron, cr.ID, cr.Name)
+		}
+	}
+	return nil
+}
+
+// notificationGroupState 对比通知组时将组内通知视为通知组的字段
+type notificationGroupState struct {
+	Name          string   `json:"name"`
+	Notifications []uint64 `json:"notifications"`
+}
+
+func (s *configSync) diffNotificationGroups(db *gorm.DB, doc *model.ConfigBundle) error {
+	var current []*model.NotificationGroup
+	if err := db.Find(&current).Error; err != nil {
+		return newGormError("%v", err)
+	}
+	var currentMembers []model.NotificationGroupNotification
+	if err := db.Find(&currentMembers).Error; err != nil {
+		return newGormError("%v", err)
+	}
+	currentState := make(map[uint64]*notificationGroupState, len(current))
+	for _, g := range current {
+		currentState[g.ID] = &notificationGroupState{Name: g.Name}
+	}
+	for _, m := range currentMembers {
+		if st, ok := currentState[m.NotificationGroupID]; ok {
+			st.Notifications = append(st.Notifications, m.NotificationID)
+		}
+	}
+	desiredMembers := make(map[uint64][]uint64)
+	for _, m := range doc.NotificationGroupNotifications {
+		desiredMembers[m.NotificationGroupID] = append(desiredMembers[m.NotificationGroupID], m.NotificationID)
+	}
+
+	var notificationIDs []uint64
+	if err := db.Model(&model.Notification{}).Pluck("id", &notificationIDs).Error; err != nil {
+		return newGormError("%v", err)
+	}
+
+	seen := make(map[uint64]bool, len(doc.NotificationGroups))
+	for _, g := range doc.NotificationGroups {
+		if err := checkConfigSyncID(model.ConfigSyncKindNotificationGroup, g.ID, g.Name, seen); err != nil {
+			return err
+		}
+		members := desiredMembers[g.ID]
+		slices.Sort(members)
+		members = slices.Compact(members)
+		for _, n := range members {
+			if !slices.Contains(notificationIDs, n) {
+				return singleton.Localizer.ErrorT("have invalid notification id")
+			}
+		}
+
+		old, ok := currentState[g.ID]
+		if ok {
+			slices.Sort(old.Notifications)
+		}
+		changed, err := s.addChange(model.ConfigSyncKindNotificationGroup, g.ID, g.Name, old,
+			&notificationGroupState{Name: g.Name, Notifications: members}, ok)
+		if err != nil {
+			return err
+		}
+		if changed {
+			s.groups = append(s.groups, g)
+			s.groupNotifications[g.ID] = members
+		}
+	}
+	for _, g := range current {
+		if !seen[g.ID] {
+			s.deletedGroups = append(s.deletedGroups, g.ID)
+			s.addDelete(model.ConfigSyncKindNotificationGroup, g.ID, g.Name)
+		}
+	}
+	return nil
+}
+
+// checkConfigSyncID 声明式配置以 ID 标识配置项，缺少 ID 时重复执行会重复创建
+func checkConfigSyncID(kind string, id uint64, name string, seen map[uint64]bool) error {
+	if id == 0 {
+		return singleton.Localizer.ErrorT("%s %q: id is required", kind, name)
+	}
+	if seen[id] {
+		return singleton.Localizer.ErrorT("%s %d: duplicate id", kind, id)
+	}
+	seen[id] = true
+	return nil
+}
+
+// addChange 记录创建或有字段变化的更新，返回是否需要写入
+func (s *configSync) addChange(kind string, id uint64, name string, current, desired any, exists bool) (bool, error) {
+	if !exists {
+		s.plan.Changes = append(s.plan.Changes, model.ConfigSyncChange{Kind: kind, Action: model.ConfigSyncActionCreate, ID: id, Name: name})
+		return true, nil
+	}
+	fields, err := model.ChangedFields(current, desired)
+	if err != nil {
+		return false, err
+	}
+	if len(fields) == 0 {
+		return false, nil
+	}
+	s.plan.Changes = append(s.plan.Changes, model.ConfigSyncChange{Kind: kind, Action: model.ConfigSyncActionUpdate, ID: id, Name: name, Fields: fields})
+	return true, nil
+}
+
+func (s *configSync) addDelete(kind string, id uint64, name string) {
+	s.plan.Changes = append(s.plan.Changes, model.ConfigSyncChange{Kind: kind, Action: model.ConfigSyncActionDelete, ID: id, Name: name})
+}
+
+// apply 在事务中写入变更
+func (s *configSync) apply(tx *gorm.DB) error {
+	for _, g := range s.groups {
+		if err := tx.Save(g).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		if err := tx.Unscoped().Delete(&model.NotificationGroupNotification{}, "notification_group_id = ?", g.ID).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		for _, n := range s.groupNotifications[g.ID] {
+			if err := tx.Create(&model.NotificationGroupNotification{NotificationGroupID: g.ID, NotificationID: n}).Error; err != nil {
+				return newGormError("%v", err)
+			}
+		}
+	}
+	for _, cr := range s.crons {
+		if err := tx.Save(cr).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	for _, m := range s.services {
+		if err := tx.Save(m).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		var skipServers []uint64
+		for k := range m.SkipServers {
+			skipServers = append(skipServers, k)
+		}
+		var err error
+		if m.Cover == 0 {
+			err = tx.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
+		} else {
+			err = tx.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id not in (?)", m.ID, skipServers).Error
+		}
+		if err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	for _, r := range s.alertRules {
+		if err := tx.Save(r).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+
+	if len(s.deletedGroups) > 0 {
+		if err := tx.Unscoped().Delete(&model.NotificationGroup{}, "id in (?)", s.deletedGroups).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		if err := tx.Unscoped().Delete(&model.NotificationGroupNotification{}, "notification_group_id in (?)", s.deletedGroups).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	if len(s.deletedCrons) > 0 {
+		if err := tx.Unscoped().Delete(&model.Cron{}, "id in (?)", s.deletedCrons).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	if len(s.deletedServices) > 0 {
+		if err := tx.Unscoped().Delete(&model.Service{}, "id in (?)", s.deletedServices).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		if err := tx.Unscoped().Delete(&model.ServiceHistory{}, "service_id in (?)", s.deletedServices).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	if len(s.deletedAlertRules) > 0 {
+		if err := tx.Unscoped().Delete(&model.AlertRule{}, "id in (?)", s.deletedAlertRules).Error; err != nil {
+			return newGormError("%v", err)
+		}
+		if err := tx.Unscoped().Delete(&model.AlertEvent{}, "alert_rule_id in (?)", s.deletedAlertRules).Error; err != nil {
+			return newGormError("%v", err)
+		}
+	}
+	if err := model.ResetIDSequence(tx, &model.NotificationGroup{}, &model.Cron{}, &model.Service{}, &model.AlertRule{}); err != nil {
+		return newGormError("%v", err)
+	}
+	return nil
+}
+
+// reload 与各配置项的接口相同，提交后刷新内存中的配置
+func (s *configSync) reload() {
+	for _, g := range s.groups {
+		singleton.OnRefreshOrAddNotificationGroup(g, s.groupNotifications[g.ID])
+	}
+	if len(s.deletedGroups) > 0 {
+		singleton.OnDeleteNotificationGroup(s.deletedGroups)
+	}
+
+	for _, cr := range s.crons {
+		if cr.TaskType == model.CronTypeCronTask {
+			var err error
+			if cr.CronJobID, err = singleton.Cron.AddFunc(cr.Scheduler, singleton.CronTrigger(cr)); err != nil {
+				// 表达式已在同步前校验
+				continue
+			}
+		}
+		singleton.OnRefreshOrAddCron(cr)
+	}
+	if len(s.deletedCrons) > 0 {
+		singleton.OnDeleteCron(s.deletedCrons)
+	}
+	if len(s.crons)+len(s.deletedCrons) > 0 {
+		singleton.UpdateCronList()
+	}
+
+	for _, m := range s.services {
+		singleton.ServiceSentinelShared.OnServiceUpdate(*m)
+	}
+	if len(s.deletedServices) > 0 {
+		singleton.ServiceSentinelShared.OnServiceDelete(s.deletedServices)
+	}
+	if len(s.services)+len(s.deletedServices) > 0 {
+		singleton.ServiceSentinelShared.UpdateServiceList()
+	}
+
+	for _, r := range s.alertRules {
+		singleton.OnRefreshOrAddAlert(r)
+	}
+	if len(s.deletedAlertRules) > 0 {
+		singleton.OnDeleteAlert(s.deletedAlertRules)
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// Get containers of server
+// @Summary Get containers of server
+// @Security BearerAuth
+// @Schemes
+// @Description Get the latest container list reported by the agent, the list is empty if the agent does not report containers
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ContainerSnapshot]
+// @Router /server/{id}/containers [get]
+func listServerContainer(c *gin.Context) (*model.ContainerSnapshot, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	singleton.ServerLock.RLock()
+	server, ok := singleton.ServerList[id]
+	var snapshot *model.ContainerSnapshot
+	if ok {
+		// 快照上报时整体替换，不会被原地修改
+		snapshot = server.Containers
+	}
+	singleton.ServerLock.RUnlock()
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("server id %d does not exist", id)
+	}
+
+	if snapshot == nil {
+		snapshot = &model.ContainerSnapshot{Containers: []model.Container{}}
+	}
+	return snapshot, nil
+}
@@ -55,6 +55,8 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	}
 	api := r.Group("api/v1")
 	api.POST("/login", authMiddleware.LoginHandler)
+	api.GET("/heartbeat/:token", commonHandler(receiveHeartbeat))
+	api.POST("/heartbeat/:token", commonHandler(receiveHeartbeat))
 
 	optionalAuth := api.Group("", optionalAuthMiddleware(authMiddleware))
 	optionalAuth.GET("/ws/server", commonHandler(serverStream))
@@ -63,6 +65,8 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	optionalAuth.GET("/service", commonHandler(showService))
 	optionalAuth.GET("/service/:id", commonHandler(listServiceHistory))
 	optionalAuth.GET("/service/server", commonHandler(listServerWithServices))
+	optionalAuth.GET("/status", commonHandler(showStatusPageByDomain))
+	optionalAuth.GET("/status/:slug", commonHandler(showStatusPage))
 
 	optionalAuth.GET("/setting", commonHandler(listConfig))
 
@@ -83,12 +87,19 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/batch-delete/user", commonHandler(batchDeleteUser))
 
 	auth.GET("/service/list", commonHandler(listService))
+	auth.GET("/service/matrix", commonHandler(showServiceLatencyMatrix))
+	auth.GET("/service/:id/sla", commonHandler(showServiceSLA))
+	auth.GET("/sla", commonHandler(listSLAReport))
+	auth.GET("/sla/export", exportSLAReport)
 	auth.POST("/service", commonHandler(createService))
 	auth.PATCH("/service/:id", commonHandler(updateService))
+	auth.GET("/service/:id/heartbeat-token", commonHandler(getServiceHeartbeatToken))
+	auth.POST("/service/:id/heartbeat-token", commonHandler(resetServiceHeartbeatToken))
 	auth.POST("/batch-delete/service", commonHandler(batchDeleteService))
 
 	auth.POST("/server-group", commonHandler(createServerGroup))
 	auth.PATCH("/server-group/:id", commonHandler(updateServerGroup))
+	auth.PATCH("/server-group/:id/agent-config", commonHandler(updateServerGroupAgentConfig))
 	auth.POST("/batch-delete/server-group", commonHandler(batchDeleteServerGroup))
 
 	auth.GET("/notification-group", commonHandler(listNotificationGroup))
@@ -100,6 +111,35 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.PATCH("/server/:id", commonHandler(updateServer))
 	auth.POST("/batch-delete/server", commonHandler(batchDeleteServer))
 	auth.POST("/force-update/server", commonHandler(forceUpdateServer))
+	auth.GET("/server/:id/traceroute", commonHandler(listTraceroute))
+	auth.POST("/server/:id/traceroute", commonHandler(createTraceroute))
+	auth.GET("/server/:id/processes", commonHandler(getServerProcesses))
+	auth.GET("/server/:id/containers", commonHandler(listServerContainer))
+	auth.GET("/server/:id/agent-config", commonHandler(getServerAgentConfig))
+	auth.PATCH("/server/:id/agent-config", commonHandler(updateServerAgentConfig))
+	auth.POST("/server/:id/secret", commonHandler(resetServerSecret))
+	auth.GET("/server-credential", commonHandler(listServerCredential))
+	auth.POST("/revoke/server-credential", commonHandler(revokeServerCredential))
+	auth.POST("/server/:id/certificate", commonHandler(issueAgentCertificate))
+	auth.GET("/agent-certificate", commonHandler(listAgentCertificate))
+	auth.POST("/revoke/agent-certificate", commonHandler(revokeAgentCertificate))
+
+	auth.GET("/upgrade-campaign", commonHandler(listUpgradeCampaign))
+	auth.GET("/upgrade-campaign/:id", commonHandler(getUpgradeCampaign))
+	auth.POST("/upgrade-campaign", commonHandler(createUpgradeCampaign))
+	auth.POST("/pause/upgrade-campaign", commonHandler(pauseUpgradeCampaign))
+	auth.POST("/resume/upgrade-campaign", commonHandler(resumeUpgradeCampaign))
+	auth.POST("/cancel/upgrade-campaign", commonHandler(cancelUpgradeCampaign))
+	auth.POST("/batch-delete/upgrade-campaign", commonHandler(batchDeleteUpgradeCampaign))
+
+	auth.GET("/pending-server", commonHandler(listPendingServer))
+	auth.POST("/approve/pending-server", commonHandler(approvePendingServer))
+	auth.POST("/reject/pending-server", commonHandler(rejectPendingServer))
+	auth.POST("/batch-delete/pending-server", commonHandler(batchDeletePendingServer))
+
+	auth.GET("/enrollment-token", commonHandler(listEnrollmentToken))
+	auth.POST("/enrollment-token", commonHandler(createEnrollmentToken))
+	auth.POST("/batch-delete/enrollment-token", commonHandler(batchDeleteEnrollmentToken))
 
 	auth.GET("/notification", commonHandler(listNotification))
 	auth.POST("/notification", commonHandler(createNotification))
@@ -110,6 +150,7 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/alert-rule", commonHandler(createAlertRule))
 	auth.PATCH("/alert-rule/:id", commonHandler(updateAlertRule))
 	auth.POST("/batch-delete/alert-rule", commonHandler(batchDeleteAlertRule))
+	auth.GET("/alert-event", commonHandler(listAlertEvent))
 
 	auth.GET("/cron", commonHandler(listCron))
 	auth.POST("/cron", commonHandler(createCron))
@@ -128,10 +169,23 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.PATCH("/nat/:id", commonHandler(updateNAT))
 	auth.POST("/batch-delete/nat", commonHandler(batchDeleteNAT))
 
+	auth.GET("/status-page", commonHandler(listStatusPage))
+	auth.POST("/status-page", commonHandler(createStatusPage))
+	auth.PATCH("/status-page/:id", commonHandler(updateStatusPage))
+	auth.POST("/batch-delete/status-page", commonHandler(batchDeleteStatusPage))
+	auth.GET("/status-page/:id/notice", commonHandler(listStatusPageNotice))
+	auth.POST("/status-page/:id/notice", commonHandler(createStatusPageNotice))
+	auth.PATCH("/status-page/:id/notice/:notice_id", commonHandler(updateStatusPageNotice))
+	auth.POST("/batch-delete/status-page-notice", commonHandler(batchDeleteStatusPageNotice))
+
 	auth.GET("/waf", commonHandler(listBlockedAddress))
 	auth.POST("/batch-delete/waf", commonHandler(batchDeleteBlockedAddress))
 
+	auth.POST("/config-sync/plan", commonHandler(planConfigSync))
+	auth.POST("/config-sync/apply", commonHandler(applyConfigSync))
+
 	auth.PATCH("/setting", commonHandler(updateConfig))
+	auth.GET("/setting/retention/dry-run", commonHandler(dryRunRetention))
 
 	r.NoRoute(fallbackToFrontend(frontendDist))
 }
@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List enrollment tokens
+// @Summary List enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description List agent enrollment tokens
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.EnrollmentToken]
+// @Router /enrollment-token [get]
+func listEnrollmentToken(c *gin.Context) ([]*model.EnrollmentToken, error) {
+	var tokens []*model.EnrollmentToken
+	if err := singleton.DB.Order("id desc").Find(&tokens).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return tokens, nil
+}
+
+// Create enrollment token
+// @Summary Create enrollment token
+// @Security BearerAuth
+// @Schemes
+// @Description Create a one-time agent enrollment token, the token is only returned once
+// @Tags auth required
+// @Accept json
+// @param request body model.EnrollmentTokenForm true "Enrollment Token Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.EnrollmentTokenResponse]
+// @Router /enrollment-token [post]
+func createEnrollmentToken(c *gin.Context) (*model.EnrollmentTokenResponse, error) {
+	var tf model.EnrollmentTokenForm
+	if err := c.ShouldBindJSON(&tf); err != nil {
+		return nil, err
+	}
+
+	if tf.ServerGroupID != 0 {
+		var count int64
+		if err := singleton.DB.Model(&model.ServerGroup{}).Where("id = ?", tf.ServerGroupID).Count(&count).Error; err != nil {
+			return nil, newGormError("%v", err)
+		}
+		if count == 0 {
+			return nil, singleton.Localizer.ErrorT("group id %d does not exist", tf.ServerGroupID)
+		}
+	}
+
+	t := model.EnrollmentToken{
+		Name:          tf.Name,
+		ServerGroupID: tf.ServerGroupID,
+		Labels:        tf.Labels,
+	}
+	if tf.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(tf.ExpiresIn) * time.Second)
+		t.ExpiresAt = &expiresAt
+	}
+
+	token, err := singleton.CreateEnrollmentToken(&t)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return &model.EnrollmentTokenResponse{ID: t.ID, Token: token}, nil
+}
+
+// Batch delete enrollment tokens
+// @Summary Batch delete enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete enrollment tokens, servers already enrolled are not affected
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/enrollment-token [post]
+func batchDeleteEnrollmentToken(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.EnrollmentToken{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// List server credentials
+// @Summary List server credentials
+// @Security BearerAuth
+// @Schemes
+// @Description List servers with their own agent secret, servers not listed still use the shared agent secret
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.ServerCredential]
+// @Router /server-credential [get]
+func listServerCredential(c *gin.Context) ([]*model.ServerCredential, error) {
+	var creds []*model.ServerCredential
+	if err := singleton.DB.Find(&creds).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return creds, nil
+}
+
+// Reset server secret
+// @Summary Reset server secret
+// @Security BearerAuth
+// @Schemes
+// @Description Generate a new agent secret for the server and disconnect connected agents, the previous secret stops working immediately and the new one is only returned once
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ServerSecretResponse]
+// @Router /server/{id}/secret [post]
+func resetServerSecret(c *gin.Context) (*model.ServerSecretResponse, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	singleton.ServerLock.RLock()
+	_, ok := singleton.ServerList[id]
+	singleton.ServerLock.RUnlock()
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("server id %d does not exist", id)
+	}
+
+	secret, err := singleton.ResetServerSecret(id)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return &model.ServerSecretResponse{Secret: secret}, nil
+}
+
+// Revoke server secrets
+// @Summary Revoke server secrets
+// @Security BearerAuth
+// @Schemes
+// @Description Revoke the agent secret of servers and disconnect their agents, they can only connect again after enrolling with a new token or resetting the secret
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /revoke/server-credential [post]
+func revokeServerCredential(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.RevokeServerSecrets(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// List agent certificates
+// @Summary List agent certificates
+// @Security BearerAuth
+// @Schemes
+// @Description List client certificates issued by the built-in CA for agent mTLS
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentCertificate]
+// @Router /agent-certificate [get]
+func listAgentCertificate(c *gin.Context) ([]*model.AgentCertificate, error) {
+	var certs []*model.AgentCertificate
+	if err := singleton.DB.Order("id desc").Find(&certs).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return certs, nil
+}
+
+// Issue agent certificate
+// @Summary Issue agent certificate
+// @Security BearerAuth
+// @Schemes
+// @Description Issue a client certificate for the server from a CSR, or together with a generated private key when the CSR is empty
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Server ID"
+// @param request body model.AgentCertificateForm true "Agent Certificate Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentCertificateResponse]
+// @Router /server/{id}/certificate [post]
+func issueAgentCertificate(c *gin.Context) (*model.AgentCertificateResponse, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	var cf model.AgentCertificateForm
+	if err := c.ShouldBindJSON(&cf); err != nil {
+		return nil, err
+	}
+
+	if singleton.AgentCA == nil {
+		return nil, singleton.Localizer.ErrorT("agent mtls is disabled")
+	}
+
+	singleton.ServerLock.RLock()
+	server, ok := singleton.ServerList[id]
+	var uuid string
+	if ok {
+		uuid = server.UUID
+	}
+	singleton.ServerLock.RUnlock()
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("server id %d does not exist", id)
+	}
+
+	certPEM, keyPEM, err := singleton.IssueAgentCertificate(id, uuid, []byte(cf.CSR))
+	if err != nil {
+		return nil, err
+	}
+	return &model.AgentCertificateResponse{
+		Certificate:   string(certPEM),
+		PrivateKey:    string(keyPEM),
+		CACertificate: string(singleton.AgentCA.CertPEM),
+	}, nil
+}
+
+// Revoke agent certificates
+// @Summary Revoke agent certificates
+// @Security BearerAuth
+// @Schemes
+// @Description Revoke agent client certificates and disconnect their agents, agents using them are refused on the next handshake
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /revoke/agent-certificate [post]
+func revokeAgentCertificate(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.RevokeAgentCertificates(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
@@ -51,8 +51,8 @@ func createNotification(c *gin.Context) (uint64, error) {
 	n.Name = nf.Name
 	n.RequestMethod = nf.RequestMethod
 	n.RequestType = nf.RequestType
-	n.RequestHeader = nf.RequestHeader
-	n.RequestBody = nf.RequestBody
+	n.RequestHeader = model.LongText(nf.RequestHeader)
+	n.RequestBody = model.LongText(nf.RequestBody)
 	n.URL = nf.URL
 	verifyTLS := nf.VerifyTLS
 	n.VerifyTLS = &verifyTLS
@@ -109,8 +109,8 @@ func updateNotification(c *gin.Context) (any, error) {
 	n.Name = nf.Name
 	n.RequestMethod = nf.RequestMethod
 	n.RequestType = nf.RequestType
-	n.RequestHeader = nf.RequestHeader
-	n.RequestBody = nf.RequestBody
+	n.RequestHeader = model.LongText(nf.RequestHeader)
+	n.RequestBody = model.LongText(nf.RequestBody)
 	n.URL = nf.URL
 	verifyTLS := nf.VerifyTLS
 	n.VerifyTLS = &verifyTLS
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List pending servers
+// @Summary List pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description List new agents waiting for approval with their reported host and connecting IP
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.PendingServer]
+// @Router /pending-server [get]
+func listPendingServer(c *gin.Context) ([]*model.PendingServer, error) {
+	var pending []*model.PendingServer
+	if err := singleton.DB.Order("id desc").Find(&pending).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return pending, nil
+}
+
+// Approve pending servers
+// @Summary Approve pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description Approve pending agents and create servers for them
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /approve/pending-server [post]
+func approvePendingServer(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.ApprovePendingServers(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Reject pending servers
+// @Summary Reject pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description Reject pending agents, their connections are refused until the record is deleted
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /reject/pending-server [post]
+func rejectPendingServer(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.RejectPendingServers(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Batch delete pending servers
+// @Summary Batch delete pending servers
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete pending or rejected agents, they enter the queue again on the next connection
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/pending-server [post]
+func batchDeletePendingServer(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DeletePendingServers(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
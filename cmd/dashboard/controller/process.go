@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// Get top processes of server
+// @Summary Get top processes of server
+// @Security BearerAuth
+// @Schemes
+// @Description Request a snapshot of the top processes by CPU and memory from the agent and wait for the result
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @param limit query uint false "Number of processes per ranking, default 10, max 50"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.TopProcesses]
+// @Router /server/{id}/processes [get]
+func getServerProcesses(c *gin.Context) (*model.TopProcesses, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var limit int
+	if s := c.Query("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return singleton.RequestTopProcesses(id, limit, singleton.TopProcessesTimeout)
+}
+
+// List alert events
+// @Summary List alert events
+// @Security BearerAuth
+// @Schemes
+// @Description List the latest 100 alert events, with the process snapshot taken when the alert was triggered
+// @Tags auth required
+// @param server_id query uint false "Server ID"
+// @param alert_rule_id query uint false "Alert Rule ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AlertEvent]
+// @Router /alert-event [get]
+func listAlertEvent(c *gin.Context) ([]*model.AlertEvent, error) {
+	query := singleton.DB.Model(&model.AlertEvent{})
+	if s := c.Query("server_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("server_id = ?", id)
+	}
+	if s := c.Query("alert_rule_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("alert_rule_id = ?", id)
+	}
+
+	var events []*model.AlertEvent
+	if err := query.Order("id desc").Limit(100).Find(&events).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return events, nil
+}
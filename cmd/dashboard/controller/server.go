@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"log"
+	"slices"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -68,6 +70,10 @@ func updateServer(c *gin.Context) (any, error) {
 	s.HideForGuest = sf.HideForGuest
 	s.EnableDDNS = sf.EnableDDNS
 	s.DDNSProfiles = sf.DDNSProfiles
+	s.Labels = sf.Labels
+	if s.WatchedUnits, err = model.NormalizeWatchedUnits(sf.WatchedUnits); err != nil {
+		return nil, singleton.Localizer.ErrorT("invalid watched units: %v", err)
+	}
 	ddnsProfilesRaw, err := utils.Json.Marshal(s.DDNSProfiles)
 	if err != nil {
 		return nil, err
@@ -79,11 +85,20 @@ func updateServer(c *gin.Context) (any, error) {
 	}
 
 	singleton.ServerLock.Lock()
-	s.CopyFromRunningServer(singleton.ServerList[s.ID])
+	old := singleton.ServerList[s.ID]
+	s.CopyFromRunningServer(old)
+	s.SystemdUnits = model.FilterSystemdUnits(s.SystemdUnits, s.WatchedUnits)
+	unitsChanged := !slices.Equal(old.WatchedUnits, s.WatchedUnits)
 	singleton.ServerList[s.ID] = &s
 	singleton.ServerLock.Unlock()
 	singleton.ReSortServer()
 
+	if unitsChanged {
+		if err := singleton.PushWatchedUnits(s.ID); err != nil {
+			log.Printf("NEZHA>> PushWatchedUnits error: %v, serverID: %d\n", err, s.ID)
+		}
+	}
+
 	return nil, nil
 }
 
@@ -111,6 +126,12 @@ func batchDeleteServer(c *gin.Context) (any, error) {
 		if err := tx.Unscoped().Delete(&model.ServerGroupServer{}, "server_id in (?)", servers).Error; err != nil {
 			return err
 		}
+		if err := tx.Delete(&model.ServerCredential{}, "server_id in (?)", servers).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&model.AgentCertificate{}, "server_id in (?)", servers).Error; err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -129,6 +150,7 @@ func batchDeleteServer(c *gin.Context) (any, error) {
 		}
 	}
 	singleton.DB.Unscoped().Delete(&model.Transfer{}, "server_id in (?)", servers)
+	singleton.DB.Unscoped().Delete(&model.AlertEvent{}, "server_id in (?)", servers)
 	singleton.AlertsLock.Unlock()
 
 	singleton.OnServerDelete(servers)
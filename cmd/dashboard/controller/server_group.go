@@ -38,8 +38,12 @@ func listServerGroup(c *gin.Context) ([]model.ServerGroupResponseItem, error) {
 		groupServers[s.ServerGroupId] = append(groupServers[s.ServerGroupId], s.ServerId)
 	}
 
+	_, isMember := c.Get(model.CtxKeyAuthorizedUser)
 	var sgRes []model.ServerGroupResponseItem
 	for _, s := range sg {
+		if !isMember {
+			s.AgentConfig = nil
+		}
 		sgRes = append(sgRes, model.ServerGroupResponseItem{
 			Group:   s,
 			Servers: groupServers[s.ID],
@@ -139,6 +143,12 @@ func updateServerGroup(c *gin.Context) (any, error) {
 		return nil, singleton.Localizer.ErrorT("have invalid server id")
 	}
 
+	// 分组成员变化后，新旧成员的生效 Agent 配置可能变化
+	oldServers, err := singleton.ServerGroupMembers([]uint64{id})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
 	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Save(&sgDB).Error; err != nil {
 			return err
@@ -161,6 +171,12 @@ func updateServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
+	if sgDB.AgentConfig != nil {
+		affected := append(oldServers, sg.Servers...)
+		slices.Sort(affected)
+		singleton.PushAgentConfigs(slices.Compact(affected))
+	}
+
 	return nil, nil
 }
 
@@ -181,7 +197,12 @@ func batchDeleteServerGroup(c *gin.Context) (any, error) {
 		return nil, err
 	}
 
-	err := singleton.DB.Transaction(func(tx *gorm.DB) error {
+	servers, err := singleton.ServerGroupMembers(sgs)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	err = singleton.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Unscoped().Delete(&model.ServerGroup{}, "id in (?)", sgs).Error; err != nil {
 			return err
 		}
@@ -195,5 +216,6 @@ func batchDeleteServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
+	singleton.PushAgentConfigs(servers)
 	return nil, nil
 }
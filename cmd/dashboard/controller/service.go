@@ -1,14 +1,17 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
+	"github.com/miekg/dns"
 
 	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
 	"github.com/nezhahq/nezha/service/singleton"
 	"gorm.io/gorm"
 )
@@ -38,8 +41,19 @@ func showService(c *gin.Context) (*model.ServiceResponse, error) {
 		return nil, err
 	}
 
+	services := res.([]interface{})[0].(map[uint64]model.ServiceResponseItem)
+	if _, isMember := c.Get(model.CtxKeyAuthorizedUser); !isMember {
+		// 结果在请求间共享，需复制后再过滤
+		guestServices := make(map[uint64]model.ServiceResponseItem, len(services))
+		for id, item := range services {
+			item.Locations = singleton.FilterServiceLocations(item.Locations)
+			guestServices[id] = item
+		}
+		services = guestServices
+	}
+
 	return &model.ServiceResponse{
-		Services:           res.([]interface{})[0].(map[uint64]model.ServiceResponseItem),
+		Services:           services,
 		CycleTransferStats: res.([]interface{})[1].(map[uint64]model.CycleTransferStats),
 	}, nil
 }
@@ -61,10 +75,27 @@ func listService(c *gin.Context) ([]*model.Service, error) {
 	if err := copier.Copy(&ss, singleton.ServiceSentinelShared.ServiceList); err != nil {
 		return nil, err
 	}
+	// 上报密钥只通过 /service/{id}/heartbeat-token 返回
+	for _, s := range ss {
+		s.HeartbeatToken = ""
+	}
 
 	return ss, nil
 }
 
+// Show service latency matrix
+// @Summary Show service latency matrix
+// @Security BearerAuth
+// @Schemes
+// @Description Show latest and percentile latency with packet loss for every service and reporting server pair
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.ServiceLatencyStats]
+// @Router /service/matrix [get]
+func showServiceLatencyMatrix(c *gin.Context) ([]*model.ServiceLatencyStats, error) {
+	return singleton.ServiceSentinelShared.LatencyMatrix(), nil
+}
+
 // List service histories by server id
 // @Summary List service histories by server id
 // @Security BearerAuth
@@ -97,7 +128,7 @@ func listServiceHistory(c *gin.Context) ([]*model.ServiceInfos, error) {
 	singleton.ServerLock.RUnlock()
 
 	var serviceHistories []*model.ServiceHistory
-	if err := singleton.DB.Model(&model.ServiceHistory{}).Select("service_id, created_at, server_id, avg_delay").
+	if err := singleton.DB.Model(&model.ServiceHistory{}).Select("service_id, created_at, server_id, avg_delay, loss, jitter").
 		Where("server_id = ?", id).Where("created_at >= ?", time.Now().Add(-24*time.Hour)).Order("service_id, created_at").
 		Scan(&serviceHistories).Error; err != nil {
 		return nil, err
@@ -124,6 +155,8 @@ func listServiceHistory(c *gin.Context) ([]*model.ServiceInfos, error) {
 		}
 		infos.CreatedAt = append(infos.CreatedAt, history.CreatedAt.Truncate(time.Minute).Unix()*1000)
 		infos.AvgDelay = append(infos.AvgDelay, history.AvgDelay)
+		infos.Loss = append(infos.Loss, history.Loss)
+		infos.Jitter = append(infos.Jitter, history.Jitter)
 	}
 
 	ret := make([]*model.ServiceInfos, 0, len(sortedServiceIDs))
@@ -189,6 +222,9 @@ func createService(c *gin.Context) (uint64, error) {
 	if err := c.ShouldBindJSON(&mf); err != nil {
 		return 0, err
 	}
+	if err := validateServiceForm(&mf); err != nil {
+		return 0, err
+	}
 
 	var m model.Service
 	m.Name = mf.Name
@@ -196,16 +232,31 @@ func createService(c *gin.Context) (uint64, error) {
 	m.Type = mf.Type
 	m.SkipServers = mf.SkipServers
 	m.Cover = mf.Cover
+	m.DispatchMode = mf.DispatchMode
+	m.PinnedServers = mf.PinnedServers
 	m.Notify = mf.Notify
 	m.NotificationGroupID = mf.NotificationGroupID
 	m.Duration = mf.Duration
 	m.LatencyNotify = mf.LatencyNotify
 	m.MinLatency = mf.MinLatency
 	m.MaxLatency = mf.MaxLatency
+	m.MaxLoss = mf.MaxLoss
+	m.MaxJitter = mf.MaxJitter
+	m.AutoTraceroute = mf.AutoTraceroute
+	m.SLARetentionDays = mf.SLARetentionDays
 	m.EnableShowInService = mf.EnableShowInService
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.HTTPOptions = mf.HTTPOptions
+	m.DNSOptions = mf.DNSOptions
+	m.TLSOptions = mf.TLSOptions
+	m.QuorumProbes = mf.QuorumProbes
+	m.QuorumFailures = mf.QuorumFailures
+	m.HeartbeatGrace = mf.HeartbeatGrace
+	if err := setHeartbeatToken(&m, mf.RegenerateHeartbeatToken); err != nil {
+		return 0, err
+	}
 
 	if err := singleton.DB.Create(&m).Error; err != nil {
 		return 0, newGormError("%v", err)
@@ -256,6 +307,9 @@ func updateService(c *gin.Context) (any, error) {
 	if err := c.ShouldBindJSON(&mf); err != nil {
 		return nil, err
 	}
+	if err := validateServiceForm(&mf); err != nil {
+		return nil, err
+	}
 	var m model.Service
 	if err := singleton.DB.First(&m, id).Error; err != nil {
 		return nil, singleton.Localizer.ErrorT("service id %d does not exist", id)
@@ -265,16 +319,31 @@ func updateService(c *gin.Context) (any, error) {
 	m.Type = mf.Type
 	m.SkipServers = mf.SkipServers
 	m.Cover = mf.Cover
+	m.DispatchMode = mf.DispatchMode
+	m.PinnedServers = mf.PinnedServers
 	m.Notify = mf.Notify
 	m.NotificationGroupID = mf.NotificationGroupID
 	m.Duration = mf.Duration
 	m.LatencyNotify = mf.LatencyNotify
 	m.MinLatency = mf.MinLatency
 	m.MaxLatency = mf.MaxLatency
+	m.MaxLoss = mf.MaxLoss
+	m.MaxJitter = mf.MaxJitter
+	m.AutoTraceroute = mf.AutoTraceroute
+	m.SLARetentionDays = mf.SLARetentionDays
 	m.EnableShowInService = mf.EnableShowInService
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.HTTPOptions = mf.HTTPOptions
+	m.DNSOptions = mf.DNSOptions
+	m.TLSOptions = mf.TLSOptions
+	m.QuorumProbes = mf.QuorumProbes
+	m.QuorumFailures = mf.QuorumFailures
+	m.HeartbeatGrace = mf.HeartbeatGrace
+	if err := setHeartbeatToken(&m, mf.RegenerateHeartbeatToken); err != nil {
+		return nil, err
+	}
 
 	if err := singleton.DB.Save(&m).Error; err != nil {
 		return nil, newGormError("%v", err)
@@ -331,3 +400,179 @@ func batchDeleteService(c *gin.Context) (any, error) {
 	singleton.ServiceSentinelShared.UpdateServiceList()
 	return nil, nil
 }
+
+// Receive heartbeat
+// @Summary Receive heartbeat
+// @Schemes
+// @Description Receive heartbeat of a push service, status can be "up" (default) or "down"
+// @Tags common
+// @param token path string true "Heartbeat token"
+// @param status query string false "up or down"
+// @param msg query string false "Message recorded with this heartbeat"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /heartbeat/{token} [get]
+func receiveHeartbeat(c *gin.Context) (any, error) {
+	successful := !strings.EqualFold(c.Query("status"), "down")
+	if err := singleton.ServiceSentinelShared.Heartbeat(c.Param("token"), successful, c.Query("msg")); err != nil {
+		if errors.Is(err, singleton.ErrHeartbeatTokenInvalid) {
+			if err := model.BlockIP(singleton.DB, c.GetString(model.CtxKeyRealIPStr), model.WAFBlockReasonTypeBruteForceToken); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	model.ClearIP(singleton.DB, c.GetString(model.CtxKeyRealIPStr))
+	return nil, nil
+}
+
+// Get heartbeat token
+// @Summary Get heartbeat token
+// @Security BearerAuth
+// @Schemes
+// @Description Get the heartbeat token of a push service, service lists do not include it
+// @Tags auth required
+// @param id path uint true "Service ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ServiceHeartbeatTokenResponse]
+// @Router /service/{id}/heartbeat-token [get]
+func getServiceHeartbeatToken(c *gin.Context) (*model.ServiceHeartbeatTokenResponse, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	singleton.ServiceSentinelShared.ServicesLock.RLock()
+	defer singleton.ServiceSentinelShared.ServicesLock.RUnlock()
+	m, ok := singleton.ServiceSentinelShared.Services[id]
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("service id %d does not exist", id)
+	}
+	if m.Type != model.TaskTypeHeartbeat {
+		return nil, singleton.Localizer.ErrorT("service id %d is not a heartbeat service", id)
+	}
+	return &model.ServiceHeartbeatTokenResponse{Token: m.HeartbeatToken}, nil
+}
+
+// Reset heartbeat token
+// @Summary Reset heartbeat token
+// @Security BearerAuth
+// @Schemes
+// @Description Generate a new heartbeat token for a push service, the previous token stops working immediately
+// @Tags auth required
+// @param id path uint true "Service ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.ServiceHeartbeatTokenResponse]
+// @Router /service/{id}/heartbeat-token [post]
+func resetServiceHeartbeatToken(c *gin.Context) (*model.ServiceHeartbeatTokenResponse, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var m model.Service
+	if err := singleton.DB.First(&m, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("service id %d does not exist", id)
+	}
+	if m.Type != model.TaskTypeHeartbeat {
+		return nil, singleton.Localizer.ErrorT("service id %d is not a heartbeat service", id)
+	}
+	if err := setHeartbeatToken(&m, true); err != nil {
+		return nil, err
+	}
+	if err := singleton.DB.Save(&m).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	if err := singleton.ServiceSentinelShared.OnServiceUpdate(m); err != nil {
+		return nil, err
+	}
+	singleton.ServiceSentinelShared.UpdateServiceList()
+	return &model.ServiceHeartbeatTokenResponse{Token: m.HeartbeatToken}, nil
+}
+
+// setHeartbeatToken 为心跳监控生成上报密钥
+func setHeartbeatToken(m *model.Service, regenerate bool) error {
+	if m.Type != model.TaskTypeHeartbeat {
+		m.HeartbeatToken = ""
+		return nil
+	}
+	if m.HeartbeatToken != "" && !regenerate {
+		return nil
+	}
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+	m.HeartbeatToken = token
+	return nil
+}
+
+func validateServiceForm(mf *model.ServiceForm) error {
+	if mf.Type == model.TaskTypeDNS {
+		if _, ok := dns.IsDomainName(strings.TrimSpace(mf.Target)); !ok {
+			return singleton.Localizer.ErrorT("invalid domain name: %s", mf.Target)
+		}
+		if mf.DNSOptions != nil {
+			if err := mf.DNSOptions.Validate(); err != nil {
+				return singleton.Localizer.ErrorT("invalid dns options: %v", err)
+			}
+		}
+	} else {
+		mf.DNSOptions = nil
+	}
+
+	if mf.Type == model.TaskTypeHeartbeat {
+		// 心跳监控由被监控方主动上报，没有监测点
+		mf.QuorumProbes, mf.QuorumFailures = 0, 0
+	}
+	if mf.MaxLoss < 0 || mf.MaxLoss > 100 {
+		return singleton.Localizer.ErrorT("max loss must be between 0 and 100")
+	}
+	if mf.MaxJitter < 0 {
+		return singleton.Localizer.ErrorT("max jitter must not be negative")
+	}
+
+	switch mf.DispatchMode {
+	case model.ServiceDispatchAll, model.ServiceDispatchRoundRobin:
+		mf.PinnedServers = nil
+	case model.ServiceDispatchPinned:
+		if len(mf.PinnedServers) == 0 {
+			return singleton.Localizer.ErrorT("pinned servers must not be empty")
+		}
+	default:
+		return singleton.Localizer.ErrorT("unknown dispatch mode: %d", mf.DispatchMode)
+	}
+	if mf.QuorumProbes > 0 && mf.QuorumFailures > mf.QuorumProbes {
+		return singleton.Localizer.ErrorT("quorum failures must not exceed the number of probes")
+	}
+
+	switch mf.Type {
+	case model.TaskTypeTLS:
+		if err := model.ValidateTLSTarget(mf.Target); err != nil {
+			return singleton.Localizer.ErrorT("invalid target: %v", err)
+		}
+		fallthrough
+	case model.TaskTypeHTTPGet:
+		// HTTP 监控仅使用其中的证书过期提醒天数
+		if mf.TLSOptions != nil {
+			if err := mf.TLSOptions.Validate(); err != nil {
+				return singleton.Localizer.ErrorT("invalid tls options: %v", err)
+			}
+		}
+	default:
+		mf.TLSOptions = nil
+	}
+
+	if mf.HTTPOptions.IsEmpty() {
+		mf.HTTPOptions = nil
+		return nil
+	}
+	if mf.Type != model.TaskTypeHTTPGet {
+		return singleton.Localizer.ErrorT("http options are only available for HTTP services")
+	}
+	if err := mf.HTTPOptions.Validate(); err != nil {
+		return singleton.Localizer.ErrorT("invalid http options: %v", err)
+	}
+	return nil
+}
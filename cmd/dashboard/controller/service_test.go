@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+func TestReceiveHeartbeat(t *testing.T) {
+	singleton.Conf = &model.Config{}
+	singleton.Loc = time.UTC
+	singleton.InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+	singleton.InitCronTask()
+
+	service := model.Service{Name: "backup", Type: model.TaskTypeHeartbeat, HeartbeatToken: "heartbeat-token", EnableShowInService: true}
+	if err := singleton.DB.Create(&service).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	singleton.NewServiceSentinel(make(chan model.Service))
+
+	heartbeat := func(token, query string) error {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/v1/heartbeat/"+token+query, nil)
+		c.Params = gin.Params{{Key: "token", Value: token}}
+		c.Set(model.CtxKeyRealIPStr, "10.0.0.1")
+		_, err := receiveHeartbeat(c)
+		return err
+	}
+	blocked := func() uint64 {
+		ip, err := utils.IPStringToBinary("10.0.0.1")
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		var w model.WAF
+		if err := singleton.DB.Where("ip = ?", ip).Limit(1).Find(&w).Error; err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		return w.Count
+	}
+	// 等待上报计入当日统计
+	waitStats := func(up, down int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			stats := singleton.ServiceSentinelShared.CopyStats()[service.ID]
+			if stats.Up[29] == up && stats.Down[29] == down {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected up %d down %d, but got %d %d", up, down, stats.Up[29], stats.Down[29])
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// 无效的上报密钥计入 WAF
+	if err := heartbeat("invalid-token", ""); err == nil {
+		t.Fatalf("Expected error for invalid token, but got nil")
+	}
+	if count := blocked(); count != 1 {
+		t.Fatalf("Expected ip to be blocked once, but got %d", count)
+	}
+
+	if err := heartbeat("heartbeat-token", ""); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	waitStats(1, 0)
+	if count := blocked(); count != 0 {
+		t.Fatalf("Expected ip to be cleared after a valid heartbeat, but got %d", count)
+	}
+
+	if err := heartbeat("heartbeat-token", "?status=down&msg=disk+full"); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	waitStats(1, 1)
+}
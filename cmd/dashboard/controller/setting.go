@@ -73,11 +73,20 @@ func updateConfig(c *gin.Context) (any, error) {
 	if !userTemplateValid {
 		return nil, errors.New("invalid user template")
 	}
+	if sf.Retention != nil {
+		if err := sf.Retention.Validate(); err != nil {
+			return nil, err
+		}
+		sf.Retention.FillDefaults()
+		singleton.Conf.Retention = *sf.Retention
+	}
 
 	singleton.Conf.Language = strings.Replace(sf.Language, "-", "_", -1)
 
 	singleton.Conf.EnableIPChangeNotification = sf.EnableIPChangeNotification
 	singleton.Conf.EnablePlainIPInNotification = sf.EnablePlainIPInNotification
+	singleton.Conf.DisableAgentAutoRegister = sf.DisableAgentAutoRegister
+	singleton.Conf.AgentApprovalRequired = sf.AgentApprovalRequired
 	singleton.Conf.Cover = sf.Cover
 	singleton.Conf.InstallHost = sf.InstallHost
 	singleton.Conf.IgnoredIPNotification = sf.IgnoredIPNotification
@@ -98,3 +107,20 @@ func updateConfig(c *gin.Context) (any, error) {
 	singleton.OnUpdateLang(singleton.Conf.Language)
 	return nil, nil
 }
+
+// Dry run retention policy
+// @Summary Dry run retention policy
+// @Security BearerAuth
+// @Schemes
+// @Description Count the rows each retention policy would delete in the next cleanup without deleting them
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.RetentionDryRunItem]
+// @Router /setting/retention/dry-run [get]
+func dryRunRetention(c *gin.Context) ([]model.RetentionDryRunItem, error) {
+	items, err := singleton.DryRunRetention()
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// Show service SLA report
+// @Summary Show service SLA report
+// @Security BearerAuth
+// @Schemes
+// @Description Show uptime, downtime, incidents and MTTR of a service for a month (2006-01) or quarter (2006-Q1), defaults to the current month
+// @Tags auth required
+// @param id path uint true "Service ID"
+// @param period query string false "Period"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.SLAReport]
+// @Router /service/{id}/sla [get]
+func showServiceSLA(c *gin.Context) (*model.SLAReport, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := slaReports(slaPeriod(c.Query("period")), id)
+	if err != nil {
+		return nil, err
+	}
+	return reports[0], nil
+}
+
+// List SLA report
+// @Summary List SLA report
+// @Security BearerAuth
+// @Schemes
+// @Description List SLA reports of all services for a month (2006-01) or quarter (2006-Q1), defaults to the current month
+// @Tags auth required
+// @param period query string false "Period"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.SLAReport]
+// @Router /sla [get]
+func listSLAReport(c *gin.Context) ([]*model.SLAReport, error) {
+	return slaReports(slaPeriod(c.Query("period")), 0)
+}
+
+// Export SLA report
+// @Summary Export SLA report
+// @Security BearerAuth
+// @Schemes
+// @Description Export SLA reports as a csv or json attachment
+// @Tags auth required
+// @param period query string false "Period"
+// @param format query string false "csv or json, defaults to csv"
+// @param service_id query uint false "Service ID, defaults to all services"
+// @Produce octet-stream
+// @Success 200 {file} file
+// @Router /sla/export [get]
+func exportSLAReport(c *gin.Context) {
+	var serviceID uint64
+	if s := c.Query("service_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusOK, newErrorResponse(err))
+			return
+		}
+		serviceID = id
+	}
+	period := slaPeriod(c.Query("period"))
+	reports, err := slaReports(period, serviceID)
+	if err != nil {
+		c.JSON(http.StatusOK, newErrorResponse(err))
+		return
+	}
+
+	switch c.DefaultQuery("format", "csv") {
+	case "json":
+		data, err := utils.Json.Marshal(reports)
+		if err != nil {
+			c.JSON(http.StatusOK, newErrorResponse(err))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=sla-%s.json", period))
+		c.Data(http.StatusOK, "application/json", data)
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=sla-%s.csv", period))
+		c.Header("Content-Type", "text/csv")
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"service_id", "service_name", "period", "uptime_percent", "downtime_seconds", "incidents", "mttr_seconds"})
+		for _, r := range reports {
+			w.Write([]string{
+				strconv.FormatUint(r.ServiceID, 10),
+				r.ServiceName,
+				r.Period,
+				strconv.FormatFloat(r.UptimePercent, 'f', 4, 64),
+				strconv.FormatUint(r.Downtime, 10),
+				strconv.FormatUint(r.Incidents, 10),
+				strconv.FormatUint(r.MTTR, 10),
+			})
+		}
+		w.Flush()
+	default:
+		c.JSON(http.StatusOK, newErrorResponse(singleton.Localizer.ErrorT("unsupported format")))
+	}
+}
+
+// slaPeriod 未指定统计周期时默认为当月
+func slaPeriod(period string) string {
+	if period == "" {
+		return time.Now().In(singleton.Loc).Format("2006-01")
+	}
+	return period
+}
+
+// slaReports 生成指定服务的 SLA 报表，serviceID 为 0 时生成全部服务的报表
+func slaReports(period string, serviceID uint64) ([]*model.SLAReport, error) {
+	if _, _, err := model.ParseSLAPeriod(period, singleton.Loc); err != nil {
+		return nil, err
+	}
+
+	singleton.ServiceSentinelShared.ServiceListLock.RLock()
+	var services []model.Service
+	for _, s := range singleton.ServiceSentinelShared.ServiceList {
+		if serviceID == 0 || s.ID == serviceID {
+			services = append(services, *s)
+		}
+	}
+	singleton.ServiceSentinelShared.ServiceListLock.RUnlock()
+	if serviceID > 0 && len(services) == 0 {
+		return nil, singleton.Localizer.ErrorT("service id %d does not exist", serviceID)
+	}
+	slices.SortFunc(services, func(a, b model.Service) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	reports := make([]*model.SLAReport, 0, len(services))
+	for i := range services {
+		report, err := singleton.GetSLAReport(&services[i], period)
+		if err != nil {
+			return nil, newGormError("%v", err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
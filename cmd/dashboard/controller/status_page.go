@@ -0,0 +1,399 @@
+package controller
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+var statusPageSlugRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Show status page by domain
+// @Summary Show status page by domain
+// @Schemes
+// @Description Show the status page whose custom domain matches the request host
+// @Tags common
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.StatusPageResponse]
+// @Router /status [get]
+func showStatusPageByDomain(c *gin.Context) (*model.StatusPageResponse, error) {
+	p := singleton.GetStatusPageByDomain(c.Request.Host)
+	if p == nil {
+		return nil, singleton.Localizer.ErrorT("status page not found")
+	}
+	_, isMember := c.Get(model.CtxKeyAuthorizedUser)
+	return getStatusPageResponse(p, isMember)
+}
+
+// Show status page by slug
+// @Summary Show status page by slug
+// @Schemes
+// @Description Show status page by slug
+// @Tags common
+// @param slug path string true "Status page slug"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.StatusPageResponse]
+// @Router /status/{slug} [get]
+func showStatusPage(c *gin.Context) (*model.StatusPageResponse, error) {
+	p := singleton.GetStatusPageBySlug(c.Param("slug"))
+	if p == nil {
+		return nil, singleton.Localizer.ErrorT("status page not found")
+	}
+	_, isMember := c.Get(model.CtxKeyAuthorizedUser)
+	return getStatusPageResponse(p, isMember)
+}
+
+func getStatusPageResponse(p *model.StatusPage, authorized bool) (*model.StatusPageResponse, error) {
+	res := &model.StatusPageResponse{
+		Slug:        p.Slug,
+		Title:       p.Title,
+		Description: p.Description,
+		Services:    singleton.ServiceSentinelShared.CopyStatsOf(p.Services),
+	}
+	if !authorized {
+		for i := range res.Services {
+			res.Services[i].Locations = singleton.FilterServiceLocations(res.Services[i].Locations)
+		}
+	}
+
+	singleton.ServerLock.RLock()
+	for _, id := range p.Servers {
+		server, ok := singleton.ServerList[id]
+		if !ok {
+			continue
+		}
+		var countryCode string
+		if server.GeoIP != nil {
+			countryCode = server.GeoIP.CountryCode
+		}
+		res.Servers = append(res.Servers, model.StreamServer{
+			ID:           server.ID,
+			Name:         server.Name,
+			PublicNote:   server.PublicNote,
+			DisplayIndex: server.DisplayIndex,
+			Host:         server.Host.Filter(),
+			State:        utils.IfOr(authorized, server.State, server.State.Filter()),
+			CountryCode:  countryCode,
+			LastActive:   server.LastActive,
+		})
+	}
+	singleton.ServerLock.RUnlock()
+
+	// 进行中、即将开始与最近 7 天结束的公告
+	if err := singleton.DB.Where("status_page_id = ? AND (ends_at IS NULL OR ends_at > ?)", p.ID, time.Now().AddDate(0, 0, -7)).
+		Order("starts_at desc").Find(&res.Notices).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return res, nil
+}
+
+// List status pages
+// @Summary List status pages
+// @Security BearerAuth
+// @Schemes
+// @Description List status pages
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.StatusPage]
+// @Router /status-page [get]
+func listStatusPage(c *gin.Context) ([]*model.StatusPage, error) {
+	var p []*model.StatusPage
+
+	singleton.StatusPageListLock.RLock()
+	defer singleton.StatusPageListLock.RUnlock()
+
+	if err := copier.Copy(&p, &singleton.StatusPageList); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Add status page
+// @Summary Add status page
+// @Security BearerAuth
+// @Schemes
+// @Description Add status page
+// @Tags auth required
+// @Accept json
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page [post]
+func createStatusPage(c *gin.Context) (uint64, error) {
+	var pf model.StatusPageForm
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return 0, err
+	}
+	if err := validateStatusPageForm(&pf, 0); err != nil {
+		return 0, err
+	}
+
+	var p model.StatusPage
+	p.Slug = pf.Slug
+	p.Title = pf.Title
+	p.Description = pf.Description
+	p.Domain = pf.Domain
+	p.Services = pf.Services
+	p.Servers = pf.Servers
+
+	if err := singleton.DB.Create(&p).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.OnStatusPageUpdate(&p)
+	singleton.UpdateStatusPageList()
+	return p.ID, nil
+}
+
+// Edit status page
+// @Summary Edit status page
+// @Security BearerAuth
+// @Schemes
+// @Description Edit status page
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /status-page/{id} [patch]
+func updateStatusPage(c *gin.Context) (any, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var pf model.StatusPageForm
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return nil, err
+	}
+	if err := validateStatusPageForm(&pf, id); err != nil {
+		return nil, err
+	}
+
+	var p model.StatusPage
+	if err := singleton.DB.First(&p, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("status page id %d does not exist", id)
+	}
+	p.Slug = pf.Slug
+	p.Title = pf.Title
+	p.Description = pf.Description
+	p.Domain = pf.Domain
+	p.Services = pf.Services
+	p.Servers = pf.Servers
+
+	if err := singleton.DB.Save(&p).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.OnStatusPageUpdate(&p)
+	singleton.UpdateStatusPageList()
+	return nil, nil
+}
+
+// Batch delete status pages
+// @Summary Batch delete status pages
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete status pages
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page [post]
+func batchDeleteStatusPage(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.StatusPage{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	if err := singleton.DB.Unscoped().Delete(&model.StatusPageNotice{}, "status_page_id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.OnStatusPageDelete(ids)
+	singleton.UpdateStatusPageList()
+	return nil, nil
+}
+
+// List notices of status page
+// @Summary List notices of status page
+// @Security BearerAuth
+// @Schemes
+// @Description List notices of status page
+// @Tags auth required
+// @param id path uint true "Status Page ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.StatusPageNotice]
+// @Router /status-page/{id}/notice [get]
+func listStatusPageNotice(c *gin.Context) ([]*model.StatusPageNotice, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var notices []*model.StatusPageNotice
+	if err := singleton.DB.Where("status_page_id = ?", id).Order("starts_at desc").Find(&notices).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return notices, nil
+}
+
+// Add notice to status page
+// @Summary Add notice to status page
+// @Security BearerAuth
+// @Schemes
+// @Description Post an incident or maintenance notice to status page
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param request body model.StatusPageNoticeForm true "Status Page Notice Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page/{id}/notice [post]
+func createStatusPageNotice(c *gin.Context) (uint64, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var nf model.StatusPageNoticeForm
+	if err := c.ShouldBindJSON(&nf); err != nil {
+		return 0, err
+	}
+	if err := validateStatusPageNoticeForm(&nf); err != nil {
+		return 0, err
+	}
+	if singleton.GetStatusPageByID(id) == nil {
+		return 0, singleton.Localizer.ErrorT("status page id %d does not exist", id)
+	}
+
+	n := model.StatusPageNotice{
+		StatusPageID: id,
+		Type:         nf.Type,
+		Title:        nf.Title,
+		Content:      nf.Content,
+		StartsAt:     nf.StartsAt,
+		EndsAt:       nf.EndsAt,
+	}
+	if err := singleton.DB.Create(&n).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+	return n.ID, nil
+}
+
+// Edit notice of status page
+// @Summary Edit notice of status page
+// @Security BearerAuth
+// @Schemes
+// @Description Edit notice of status page, set ends_at to resolve an incident
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param notice_id path uint true "Notice ID"
+// @param request body model.StatusPageNoticeForm true "Status Page Notice Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /status-page/{id}/notice/{notice_id} [patch]
+func updateStatusPageNotice(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	noticeID, err := strconv.ParseUint(c.Param("notice_id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var nf model.StatusPageNoticeForm
+	if err := c.ShouldBindJSON(&nf); err != nil {
+		return nil, err
+	}
+	if err := validateStatusPageNoticeForm(&nf); err != nil {
+		return nil, err
+	}
+
+	var n model.StatusPageNotice
+	if err := singleton.DB.First(&n, "id = ? AND status_page_id = ?", noticeID, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("notice id %d does not exist", noticeID)
+	}
+	n.Type = nf.Type
+	n.Title = nf.Title
+	n.Content = nf.Content
+	n.StartsAt = nf.StartsAt
+	n.EndsAt = nf.EndsAt
+
+	if err := singleton.DB.Save(&n).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Batch delete notices of status page
+// @Summary Batch delete notices of status page
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete notices of status page
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page-notice [post]
+func batchDeleteStatusPageNotice(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.StatusPageNotice{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+func validateStatusPageForm(pf *model.StatusPageForm, id uint64) error {
+	if !statusPageSlugRegexp.MatchString(pf.Slug) {
+		return singleton.Localizer.ErrorT("invalid slug: %s", pf.Slug)
+	}
+	if p := singleton.GetStatusPageBySlug(pf.Slug); p != nil && p.ID != id {
+		return singleton.Localizer.ErrorT("slug %s is already in use", pf.Slug)
+	}
+	if pf.Domain != "" {
+		if p := singleton.GetStatusPageByDomain(pf.Domain); p != nil && p.ID != id {
+			return singleton.Localizer.ErrorT("domain %s is already in use", pf.Domain)
+		}
+		// 内网穿透按域名优先匹配，相同域名的状态页无法访问
+		if singleton.GetNATConfigByDomain(pf.Domain) != nil {
+			return singleton.Localizer.ErrorT("domain %s is already in use", pf.Domain)
+		}
+	}
+	return nil
+}
+
+func validateStatusPageNoticeForm(nf *model.StatusPageNoticeForm) error {
+	if nf.Type != model.StatusPageNoticeIncident && nf.Type != model.StatusPageNoticeMaintenance {
+		return singleton.Localizer.ErrorT("unknown notice type: %s", nf.Type)
+	}
+	if nf.StartsAt.IsZero() {
+		nf.StartsAt = time.Now()
+	}
+	if nf.EndsAt != nil && nf.EndsAt.Before(nf.StartsAt) {
+		return singleton.Localizer.ErrorT("notice cannot end before it starts")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List traceroute records of server
+// @Summary List traceroute records of server
+// @Security BearerAuth
+// @Schemes
+// @Description List the latest 20 traceroute records of server
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.Traceroute]
+// @Router /server/{id}/traceroute [get]
+func listTraceroute(c *gin.Context) ([]*model.Traceroute, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var ts []*model.Traceroute
+	if err := singleton.DB.Where("server_id = ?", id).Order("id desc").Limit(20).Find(&ts).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return ts, nil
+}
+
+// Request traceroute from server
+// @Summary Request traceroute from server
+// @Security BearerAuth
+// @Schemes
+// @Description Request traceroute from server, the result will be reported by agent asynchronously
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Server ID"
+// @param request body model.TracerouteForm true "Traceroute Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /server/{id}/traceroute [post]
+func createTraceroute(c *gin.Context) (uint64, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var tf model.TracerouteForm
+	if err := c.ShouldBindJSON(&tf); err != nil {
+		return 0, err
+	}
+	task := model.TaskTraceroute{
+		Target:  strings.TrimSpace(tf.Target),
+		MaxHops: tf.MaxHops,
+		Count:   tf.Count,
+	}
+	if err := task.Validate(); err != nil {
+		return 0, singleton.Localizer.ErrorT("invalid traceroute request: %v", err)
+	}
+
+	t, err := singleton.RequestTraceroute(id, 0, &task, model.TracerouteTriggerManual)
+	if err != nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
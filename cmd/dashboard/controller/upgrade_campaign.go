@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/service/singleton"
+)
+
+// List upgrade campaigns
+// @Summary List upgrade campaigns
+// @Security BearerAuth
+// @Schemes
+// @Description List agent upgrade campaigns with progress
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.UpgradeCampaignResponseItem]
+// @Router /upgrade-campaign [get]
+func listUpgradeCampaign(c *gin.Context) ([]model.UpgradeCampaignResponseItem, error) {
+	var campaigns []model.UpgradeCampaign
+	if err := singleton.DB.Order("id desc").Find(&campaigns).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var servers []*model.UpgradeCampaignServer
+	if err := singleton.DB.Find(&servers).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	campaignServers := make(map[uint64][]*model.UpgradeCampaignServer)
+	for _, s := range servers {
+		campaignServers[s.CampaignID] = append(campaignServers[s.CampaignID], s)
+	}
+
+	items := make([]model.UpgradeCampaignResponseItem, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		items = append(items, model.UpgradeCampaignResponseItem{
+			Campaign: campaign,
+			Progress: model.NewUpgradeCampaignProgress(campaignServers[campaign.ID]),
+		})
+	}
+	return items, nil
+}
+
+// Get upgrade campaign
+// @Summary Get upgrade campaign
+// @Security BearerAuth
+// @Schemes
+// @Description Get agent upgrade campaign with progress and per-server results
+// @Tags auth required
+// @param id path uint true "Upgrade Campaign ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.UpgradeCampaignResponseItem]
+// @Router /upgrade-campaign/{id} [get]
+func getUpgradeCampaign(c *gin.Context) (*model.UpgradeCampaignResponseItem, error) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var campaign model.UpgradeCampaign
+	if err := singleton.DB.First(&campaign, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("upgrade campaign id %d does not exist", id)
+	}
+	var servers []*model.UpgradeCampaignServer
+	if err := singleton.DB.Where("campaign_id = ?", id).Order("wave, id").Find(&servers).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.UpgradeCampaignResponseItem{
+		Campaign: campaign,
+		Progress: model.NewUpgradeCampaignProgress(servers),
+		Servers:  servers,
+	}, nil
+}
+
+// Create upgrade campaign
+// @Summary Create upgrade campaign
+// @Security BearerAuth
+// @Schemes
+// @Description Create agent upgrade campaign, servers are upgraded to the target version wave by wave
+// @Tags auth required
+// @Accept json
+// @param request body model.UpgradeCampaignForm true "Upgrade Campaign Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /upgrade-campaign [post]
+func createUpgradeCampaign(c *gin.Context) (uint64, error) {
+	var uf model.UpgradeCampaignForm
+	if err := c.ShouldBindJSON(&uf); err != nil {
+		return 0, err
+	}
+	uf.Version = strings.TrimSpace(uf.Version)
+	if uf.Version == "" {
+		return 0, singleton.Localizer.ErrorT("target version is required")
+	}
+	if uf.CanaryPercent > 100 {
+		return 0, singleton.Localizer.ErrorT("canary percent must be between 0 and 100")
+	}
+
+	servers := slices.Clone(uf.Servers)
+	if len(uf.ServerGroups) > 0 {
+		members, err := singleton.ServerGroupMembers(uf.ServerGroups)
+		if err != nil {
+			return 0, newGormError("%v", err)
+		}
+		servers = append(servers, members...)
+	}
+	slices.Sort(servers)
+	servers = slices.Compact(servers)
+	if len(servers) == 0 {
+		return 0, singleton.Localizer.ErrorT("no server selected")
+	}
+
+	var count int64
+	if err := singleton.DB.Model(&model.Server{}).Where("id in (?)", servers).Count(&count).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+	if count != int64(len(servers)) {
+		return 0, singleton.Localizer.ErrorT("have invalid server id")
+	}
+
+	campaign := model.UpgradeCampaign{
+		Name:           uf.Name,
+		Version:        uf.Version,
+		CanaryPercent:  uf.CanaryPercent,
+		BatchSize:      uf.BatchSize,
+		Timeout:        uf.Timeout,
+		PauseOnFailure: uf.PauseOnFailure,
+	}
+	if err := singleton.CreateUpgradeCampaign(&campaign, servers); err != nil {
+		return 0, newGormError("%v", err)
+	}
+	return campaign.ID, nil
+}
+
+// Pause upgrade campaigns
+// @Summary Pause upgrade campaigns
+// @Security BearerAuth
+// @Schemes
+// @Description Pause running upgrade campaigns, servers already upgrading are confirmed after resuming
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /pause/upgrade-campaign [post]
+func pauseUpgradeCampaign(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+	if err := singleton.PauseUpgradeCampaigns(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Resume upgrade campaigns
+// @Summary Resume upgrade campaigns
+// @Security BearerAuth
+// @Schemes
+// @Description Resume paused upgrade campaigns
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /resume/upgrade-campaign [post]
+func resumeUpgradeCampaign(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+	if err := singleton.ResumeUpgradeCampaigns(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Cancel upgrade campaigns
+// @Summary Cancel upgrade campaigns
+// @Security BearerAuth
+// @Schemes
+// @Description Cancel unfinished upgrade campaigns, servers not yet upgrading are skipped
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /cancel/upgrade-campaign [post]
+func cancelUpgradeCampaign(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+	if err := singleton.CancelUpgradeCampaigns(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+// Batch delete upgrade campaigns
+// @Summary Batch delete upgrade campaigns
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete upgrade campaigns and their per-server results
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/upgrade-campaign [post]
+func batchDeleteUpgradeCampaign(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+	if err := singleton.DeleteUpgradeCampaigns(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
 
 	"github.com/nezhahq/nezha/cmd/dashboard/controller"
 	"github.com/nezhahq/nezha/cmd/dashboard/rpc"
@@ -60,6 +61,10 @@ func initSystem() {
 	// 启动 singleton 包下的所有服务
 	singleton.LoadSingleton()
 
+	if err := singleton.InitAgentMTLS(); err != nil {
+		panic(err)
+	}
+
 	// 每天的3:30 对 监控记录 和 流量记录 进行清理
 	if _, err := singleton.Cron.AddFunc("0 30 3 * * *", singleton.CleanServiceHistory); err != nil {
 		panic(err)
@@ -69,6 +74,11 @@ func initSystem() {
 	if _, err := singleton.Cron.AddFunc("0 0 * * * *", singleton.RecordTransferHourlyUsage); err != nil {
 		panic(err)
 	}
+
+	// 每 10 秒推进进行中的 Agent 升级计划
+	if _, err := singleton.Cron.AddFunc("@every 10s", singleton.ProcessUpgradeCampaigns); err != nil {
+		panic(err)
+	}
 }
 
 // @title           Nezha Monitoring API
@@ -95,7 +105,8 @@ func initSystem() {
 func main() {
 	flag.BoolVar(&dashboardCliParam.Version, "v", false, "查看当前版本号")
 	flag.StringVar(&dashboardCliParam.ConfigFile, "c", "data/config.yaml", "配置文件路径")
-	flag.StringVar(&dashboardCliParam.DatebaseLocation, "db", "data/sqlite.db", "Sqlite3数据库文件路径")
+	flag.StringVar(&dashboardCliParam.DatebaseLocation, "db", "data/sqlite.db", "Sqlite3数据库文件路径，使用 postgres 或 mysql 时请在配置文件中设置 db_driver 与 dsn")
+	flag.Usage = usage
 	flag.Parse()
 
 	if dashboardCliParam.Version {
@@ -103,6 +114,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			log.Fatalf("NEZHA>> ERROR: %v", err)
+		}
+		return
+	}
+
 	// 初始化 dao 包
 	singleton.InitFrontendTemplates()
 	singleton.InitConfigFromPath(dashboardCliParam.ConfigFile)
@@ -130,6 +148,26 @@ func main() {
 	http2Server := &http2.Server{}
 	muxServer := &http.Server{Handler: h2c.NewHandler(muxHandler, http2Server), ReadHeaderTimeout: time.Second * 5}
 
+	// 开启双向 TLS 时在独立端口提供 Agent gRPC 服务
+	var mtlsServer *grpc.Server
+	if singleton.Conf.AgentMTLS.Enabled {
+		tlsConfig, err := singleton.AgentTLSConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ml, err := net.Listen("tcp", fmt.Sprintf("%s:%d", singleton.Conf.ListenHost, singleton.Conf.AgentMTLS.ListenPort))
+		if err != nil {
+			log.Fatal(err)
+		}
+		mtlsServer = rpc.ServeMTLSRPC(tlsConfig)
+		go func() {
+			log.Printf("NEZHA>> Agent mTLS gRPC::START ON %s:%d", singleton.Conf.ListenHost, singleton.Conf.AgentMTLS.ListenPort)
+			if err := mtlsServer.Serve(ml); err != nil {
+				log.Printf("NEZHA>> Agent mTLS gRPC ERROR: %v", err)
+			}
+		}()
+	}
+
 	if err := graceful.Graceful(func() error {
 		log.Printf("NEZHA>> Dashboard::START ON %s:%d", singleton.Conf.ListenHost, singleton.Conf.ListenPort)
 		return muxServer.Serve(l)
@@ -137,6 +175,9 @@ func main() {
 		log.Println("NEZHA>> Graceful::START")
 		singleton.RecordTransferHourlyUsage()
 		log.Println("NEZHA>> Graceful::END")
+		if mtlsServer != nil {
+			mtlsServer.Stop()
+		}
 		return muxServer.Shutdown(c)
 	}); err != nil {
 		log.Printf("NEZHA>> ERROR: %v", err)
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"github.com/nezhahq/nezha/model"
+)
+
+// taskScheduler 按服务监控的下发模式选择每轮执行任务的服务器
+//
+//   - ServiceDispatchAll：按 SortedServerList 顺序选取全部符合 Cover 规则的在线服务器
+//   - ServiceDispatchRoundRobin：从上一轮选中的服务器之后开始，选取下一台符合规则的在线服务器
+//   - ServiceDispatchPinned：按 PinnedServers 顺序选取其中的在线服务器
+//
+// 配置了 QuorumProbes 时，每轮最多选取该数量的服务器（轮询模式下为连续的多台）
+// Agent 版本过旧、无法执行该任务的服务器不会被选中
+type taskScheduler struct {
+	lastServer map[uint64]uint64 // [service_id] -> 轮询模式下上一轮最后选中的服务器 ID
+}
+
+func newTaskScheduler() *taskScheduler {
+	return &taskScheduler{
+		lastServer: make(map[uint64]uint64),
+	}
+}
+
+// pick 返回本轮需要下发任务的服务器，servers 为按展示顺序排列的服务器列表
+func (s *taskScheduler) pick(task *model.Service, servers []*model.Server) []*model.Server {
+	limit := int(task.QuorumProbes)
+
+	switch task.DispatchMode {
+	case model.ServiceDispatchRoundRobin:
+		if limit == 0 {
+			limit = 1
+		}
+		return s.pickRoundRobin(task, servers, limit)
+	case model.ServiceDispatchPinned:
+		online := make(map[uint64]*model.Server, len(servers))
+		for _, server := range servers {
+			if server != nil && server.TaskStream != nil && task.SupportsAgent(agentVersion(server)) {
+				online[server.ID] = server
+			}
+		}
+		var picked []*model.Server
+		for _, id := range task.PinnedServers {
+			if server, ok := online[id]; ok && (limit == 0 || len(picked) < limit) {
+				picked = append(picked, server)
+			}
+		}
+		return picked
+	default:
+		var picked []*model.Server
+		for _, server := range servers {
+			if limit > 0 && len(picked) >= limit {
+				break
+			}
+			if canDispatch(task, server) {
+				picked = append(picked, server)
+			}
+		}
+		return picked
+	}
+}
+
+func (s *taskScheduler) pickRoundRobin(task *model.Service, servers []*model.Server, limit int) []*model.Server {
+	// 从上一轮选中的服务器之后开始，该服务器已不在列表中时从头开始
+	start := 0
+	if last, ok := s.lastServer[task.ID]; ok {
+		for i, server := range servers {
+			if server != nil && server.ID == last {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	var picked []*model.Server
+	for i := 0; i < len(servers) && len(picked) < limit; i++ {
+		server := servers[(start+i)%len(servers)]
+		if canDispatch(task, server) {
+			picked = append(picked, server)
+		}
+	}
+	if len(picked) > 0 {
+		s.lastServer[task.ID] = picked[len(picked)-1].ID
+	}
+	return picked
+}
+
+func canDispatch(task *model.Service, server *model.Server) bool {
+	return server != nil && server.TaskStream != nil && task.CanDispatchTo(server.ID) &&
+		task.SupportsAgent(agentVersion(server))
+}
+
+func agentVersion(server *model.Server) string {
+	if server.Host == nil {
+		return ""
+	}
+	return server.Host.Version
+}
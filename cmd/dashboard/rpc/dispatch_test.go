@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+type testTaskStream struct {
+	pb.NezhaService_RequestTaskServer
+}
+
+func testServers(online ...bool) []*model.Server {
+	var servers []*model.Server
+	for i, o := range online {
+		server := &model.Server{Common: model.Common{ID: uint64(i + 1)}}
+		if o {
+			server.TaskStream = &testTaskStream{}
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func pickedIDs(servers []*model.Server) []uint64 {
+	var ids []uint64
+	for _, server := range servers {
+		ids = append(ids, server.ID)
+	}
+	return ids
+}
+
+func TestTaskSchedulerPick(t *testing.T) {
+	servers := testServers(true, true, false, true, true)
+
+	cases := []struct {
+		name     string
+		task     model.Service
+		expected []uint64
+	}{
+		{"all", model.Service{}, []uint64{1, 2, 4, 5}},
+		{"all with skip", model.Service{SkipServers: map[uint64]bool{2: true}}, []uint64{1, 4, 5}},
+		{"ignore all", model.Service{Cover: model.ServiceCoverIgnoreAll, SkipServers: map[uint64]bool{2: true, 3: true}}, []uint64{2}},
+		{"all with quorum probes", model.Service{QuorumProbes: 2, SkipServers: map[uint64]bool{1: true}}, []uint64{2, 4}},
+		{"pinned", model.Service{DispatchMode: model.ServiceDispatchPinned, PinnedServers: []uint64{5, 3, 1, 9}}, []uint64{5, 1}},
+		{"pinned with quorum probes", model.Service{DispatchMode: model.ServiceDispatchPinned, PinnedServers: []uint64{4, 2, 1}, QuorumProbes: 2}, []uint64{4, 2}},
+	}
+	for _, c := range cases {
+		if got := pickedIDs(newTaskScheduler().pick(&c.task, servers)); !slices.Equal(got, c.expected) {
+			t.Fatalf("%s: expected %v, but got %v", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestTaskSchedulerAgentVersion(t *testing.T) {
+	servers := testServers(true, true, true)
+	servers[0].Host = &model.Host{Version: "1.9.0"}
+	servers[1].Host = &model.Host{Version: model.TaskHTTPMinAgentVersion}
+
+	// 旧版 Agent 无法执行带断言的 HTTP 任务
+	task := model.Service{Type: model.TaskTypeHTTPGet, HTTPOptions: &model.ServiceHTTPOptions{Keyword: "ok"}}
+	if got := pickedIDs(newTaskScheduler().pick(&task, servers)); !slices.Equal(got, []uint64{2}) {
+		t.Fatalf("Expected [2], but got %v", got)
+	}
+	task.DispatchMode = model.ServiceDispatchPinned
+	task.PinnedServers = []uint64{1, 2, 3}
+	if got := pickedIDs(newTaskScheduler().pick(&task, servers)); !slices.Equal(got, []uint64{2}) {
+		t.Fatalf("Expected [2], but got %v", got)
+	}
+
+	// 只下发 URL 的 HTTP 任务不受限制
+	plain := model.Service{Type: model.TaskTypeHTTPGet}
+	if got := pickedIDs(newTaskScheduler().pick(&plain, servers)); !slices.Equal(got, []uint64{1, 2, 3}) {
+		t.Fatalf("Expected [1 2 3], but got %v", got)
+	}
+}
+
+func TestTaskSchedulerRoundRobin(t *testing.T) {
+	servers := testServers(true, true, false, true)
+	s := newTaskScheduler()
+
+	task := model.Service{Common: model.Common{ID: 1}, DispatchMode: model.ServiceDispatchRoundRobin}
+	var got []uint64
+	for i := 0; i < 5; i++ {
+		got = append(got, pickedIDs(s.pick(&task, servers))...)
+	}
+	if expected := []uint64{1, 2, 4, 1, 2}; !slices.Equal(got, expected) {
+		t.Fatalf("Expected %v, but got %v", expected, got)
+	}
+
+	// 每个服务监控独立轮询
+	other := model.Service{Common: model.Common{ID: 2}, DispatchMode: model.ServiceDispatchRoundRobin, QuorumProbes: 2}
+	if got := pickedIDs(s.pick(&other, servers)); !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("Expected [1 2], but got %v", got)
+	}
+	if got := pickedIDs(s.pick(&other, servers)); !slices.Equal(got, []uint64{4, 1}) {
+		t.Fatalf("Expected [4 1], but got %v", got)
+	}
+
+	// 上一轮选中的服务器被删除后从头开始
+	servers = servers[2:]
+	if got := pickedIDs(s.pick(&task, servers)); !slices.Equal(got, []uint64{4}) {
+		t.Fatalf("Expected [4], but got %v", got)
+	}
+
+	servers = testServers(false, false)
+	if got := s.pick(&task, servers); len(got) != 0 {
+		t.Fatalf("Expected no server, but got %v", pickedIDs(got))
+	}
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 
@@ -27,6 +29,13 @@ func ServeRPC() *grpc.Server {
 	return server
 }
 
+// ServeMTLSRPC 使用内置 CA 校验客户端证书的 gRPC 服务，与 ServeRPC 共用同一个 NezhaHandler
+func ServeMTLSRPC(tlsConfig *tls.Config) *grpc.Server {
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)), grpc.ChainUnaryInterceptor(getRealIp, waf))
+	proto.RegisterNezhaServiceServer(server, rpcService.NezhaHandlerSingleton)
+	return server
+}
+
 func waf(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	realip, _ := ctx.Value(model.CtxKeyRealIP{}).(string)
 	if err := model.CheckIP(singleton.DB, realip); err != nil {
@@ -74,41 +83,13 @@ func getRealIp(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
 	return handler(ctx, req)
 }
 
+// DispatchTask 按服务监控的下发模式将任务下发给选中的服务器，监控结果按上报服务器分别统计
 func DispatchTask(serviceSentinelDispatchBus <-chan model.Service) {
-	workedServerIndex := 0
+	scheduler := newTaskScheduler()
 	for task := range serviceSentinelDispatchBus {
-		round := 0
-		endIndex := workedServerIndex
 		singleton.SortedServerLock.RLock()
-		// 如果已经轮了一整圈又轮到自己，没有合适机器去请求，跳出循环
-		for round < 1 || workedServerIndex < endIndex {
-			// 如果到了圈尾，再回到圈头，圈数加一，游标重置
-			if workedServerIndex >= len(singleton.SortedServerList) {
-				workedServerIndex = 0
-				round++
-				continue
-			}
-			// 如果服务器不在线，跳过这个服务器
-			if singleton.SortedServerList[workedServerIndex].TaskStream == nil {
-				workedServerIndex++
-				continue
-			}
-			// 如果此任务不可使用此服务器请求，跳过这个服务器（有些 IPv6 only 开了 NAT64 的机器请求 IPv4 总会出问题）
-			if (task.Cover == model.ServiceCoverAll && task.SkipServers[singleton.SortedServerList[workedServerIndex].ID]) ||
-				(task.Cover == model.ServiceCoverIgnoreAll && !task.SkipServers[singleton.SortedServerList[workedServerIndex].ID]) {
-				workedServerIndex++
-				continue
-			}
-			if task.Cover == model.ServiceCoverIgnoreAll && task.SkipServers[singleton.SortedServerList[workedServerIndex].ID] {
-				singleton.SortedServerList[workedServerIndex].TaskStream.Send(task.PB())
-				workedServerIndex++
-				continue
-			}
-			if task.Cover == model.ServiceCoverAll && !task.SkipServers[singleton.SortedServerList[workedServerIndex].ID] {
-				singleton.SortedServerList[workedServerIndex].TaskStream.Send(task.PB())
-				workedServerIndex++
-				continue
-			}
+		for _, server := range scheduler.pick(&task, singleton.SortedServerList) {
+			server.TaskStream.Send(task.PB())
 		}
 		singleton.SortedServerLock.RUnlock()
 	}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// AgentCertificate 内置 CA 签发的 Agent 客户端证书，证书的 CommonName 为服务器 UUID
+type AgentCertificate struct {
+	Common
+	ServerID  uint64     `gorm:"index" json:"server_id"`
+	Serial    string     `gorm:"uniqueIndex;size:64" json:"serial"` // 十六进制序列号
+	NotAfter  time.Time  `json:"not_after"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (c *AgentCertificate) Revoked() bool {
+	return c.RevokedAt != nil
+}
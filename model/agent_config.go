@@ -0,0 +1,112 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// AgentConfig 由面板下发的 Agent 配置，字段为空时表示不覆盖 Agent 本地配置
+// 生效配置由服务器所在分组的配置按分组 ID 依次合并，再合并服务器自身的配置
+type AgentConfig struct {
+	ReportDelay           *uint32  `json:"report_delay,omitempty" validate:"optional"`            // 状态上报间隔，秒，1-4
+	IPReportPeriod        *uint32  `json:"ip_report_period,omitempty" validate:"optional"`        // IP 上报周期，秒，不小于 30
+	DisableCommandExecute *bool    `json:"disable_command_execute,omitempty" validate:"optional"` // 禁止执行计划任务与触发任务
+	DisableTerminal       *bool    `json:"disable_terminal,omitempty" validate:"optional"`        // 禁用终端
+	DisableFileManager    *bool    `json:"disable_file_manager,omitempty" validate:"optional"`    // 禁用文件管理
+	DisableNat            *bool    `json:"disable_nat,omitempty" validate:"optional"`             // 禁用内网穿透
+	DisableSendQuery      *bool    `json:"disable_send_query,omitempty" validate:"optional"`      // 禁止服务监控
+	DisableAutoUpdate     *bool    `json:"disable_auto_update,omitempty" validate:"optional"`     // 禁用自动更新
+	Temperature           *bool    `json:"temperature,omitempty" validate:"optional"`             // 上报温度
+	GPU                   *bool    `json:"gpu,omitempty" validate:"optional"`                     // 上报 GPU 信息
+	NICAllowlist          []string `json:"nic_allowlist,omitempty" validate:"optional"`           // 统计流量的网卡
+	DiskAllowlist         []string `json:"disk_allowlist,omitempty" validate:"optional"`          // 统计用量的挂载点
+}
+
+// TaskAgentConfig 配置下发任务，序列化后作为 pb.Task.Data 下发，pb.Task.Id 为配置版本
+// Agent 应用后以相同的 Id 与任务类型上报结果
+type TaskAgentConfig struct {
+	Version uint64       `json:"version"`
+	Config  *AgentConfig `json:"config"`
+}
+
+// IsEmpty 配置是否没有任何覆盖项
+func (c *AgentConfig) IsEmpty() bool {
+	return c == nil ||
+		(c.ReportDelay == nil && c.IPReportPeriod == nil && c.DisableCommandExecute == nil && c.DisableTerminal == nil &&
+			c.DisableFileManager == nil && c.DisableNat == nil && c.DisableSendQuery == nil && c.DisableAutoUpdate == nil &&
+			c.Temperature == nil && c.GPU == nil && c.NICAllowlist == nil && c.DiskAllowlist == nil)
+}
+
+// Merge 使用 o 中已设置的字段覆盖 c，返回合并后的配置
+func (c AgentConfig) Merge(o *AgentConfig) AgentConfig {
+	if o == nil {
+		return c
+	}
+	if o.ReportDelay != nil {
+		c.ReportDelay = o.ReportDelay
+	}
+	if o.IPReportPeriod != nil {
+		c.IPReportPeriod = o.IPReportPeriod
+	}
+	if o.DisableCommandExecute != nil {
+		c.DisableCommandExecute = o.DisableCommandExecute
+	}
+	if o.DisableTerminal != nil {
+		c.DisableTerminal = o.DisableTerminal
+	}
+	if o.DisableFileManager != nil {
+		c.DisableFileManager = o.DisableFileManager
+	}
+	if o.DisableNat != nil {
+		c.DisableNat = o.DisableNat
+	}
+	if o.DisableSendQuery != nil {
+		c.DisableSendQuery = o.DisableSendQuery
+	}
+	if o.DisableAutoUpdate != nil {
+		c.DisableAutoUpdate = o.DisableAutoUpdate
+	}
+	if o.Temperature != nil {
+		c.Temperature = o.Temperature
+	}
+	if o.GPU != nil {
+		c.GPU = o.GPU
+	}
+	if o.NICAllowlist != nil {
+		c.NICAllowlist = o.NICAllowlist
+	}
+	if o.DiskAllowlist != nil {
+		c.DiskAllowlist = o.DiskAllowlist
+	}
+	return c
+}
+
+// Validate 检查配置取值范围
+func (c *AgentConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.ReportDelay != nil && (*c.ReportDelay < 1 || *c.ReportDelay > 4) {
+		return errors.New("report_delay must be between 1 and 4")
+	}
+	if c.IPReportPeriod != nil && *c.IPReportPeriod < 30 {
+		return errors.New("ip_report_period must be at least 30")
+	}
+	return nil
+}
+
+// Version 根据配置内容计算版本号，空配置的版本号为 0，取 53 位以便前端精确展示
+func (c *AgentConfig) Version() uint64 {
+	if c.IsEmpty() {
+		return 0
+	}
+	data, _ := utils.Json.Marshal(c)
+	sum := sha256.Sum256(data)
+	if v := binary.BigEndian.Uint64(sum[:8]) & (1<<53 - 1); v != 0 {
+		return v
+	}
+	return 1
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAgentConfigMerge(t *testing.T) {
+	delay1, delay3 := uint32(1), uint32(3)
+	yes, no := true, false
+
+	group := &AgentConfig{ReportDelay: &delay3, DisableTerminal: &yes, NICAllowlist: []string{"eth0"}}
+	server := &AgentConfig{ReportDelay: &delay1, DisableTerminal: &no}
+
+	var merged AgentConfig
+	merged = merged.Merge(group).Merge(server).Merge(nil)
+	if *merged.ReportDelay != 1 || *merged.DisableTerminal {
+		t.Fatalf("Expected server config to override group config, but got %+v", merged)
+	}
+	if !slices.Equal(merged.NICAllowlist, []string{"eth0"}) {
+		t.Fatalf("Expected nic allowlist from group, but got %v", merged.NICAllowlist)
+	}
+	if merged.DisableFileManager != nil {
+		t.Fatalf("Expected unset field to stay unset, but got %v", *merged.DisableFileManager)
+	}
+}
+
+func TestAgentConfigVersion(t *testing.T) {
+	if v := (&AgentConfig{}).Version(); v != 0 {
+		t.Fatalf("Expected version 0 for empty config, but got %d", v)
+	}
+	var nilConfig *AgentConfig
+	if v := nilConfig.Version(); v != 0 {
+		t.Fatalf("Expected version 0 for nil config, but got %d", v)
+	}
+
+	yes := true
+	a := &AgentConfig{GPU: &yes}
+	b := &AgentConfig{GPU: &yes}
+	if a.Version() == 0 || a.Version() != b.Version() {
+		t.Fatalf("Expected same non-zero version for same config, but got %d and %d", a.Version(), b.Version())
+	}
+	if a.Version() >= 1<<53 {
+		t.Fatalf("Expected version to fit in 53 bits, but got %d", a.Version())
+	}
+	b.DiskAllowlist = []string{"/"}
+	if a.Version() == b.Version() {
+		t.Fatalf("Expected different versions for different configs")
+	}
+}
+
+func TestAgentConfigValidate(t *testing.T) {
+	zero, five, ten := uint32(0), uint32(5), uint32(10)
+	cases := []struct {
+		config *AgentConfig
+		valid  bool
+	}{
+		{nil, true},
+		{&AgentConfig{}, true},
+		{&AgentConfig{ReportDelay: &zero}, false},
+		{&AgentConfig{ReportDelay: &five}, false},
+		{&AgentConfig{IPReportPeriod: &ten}, false},
+	}
+	for i, c := range cases {
+		if err := c.config.Validate(); (err == nil) != c.valid {
+			t.Fatalf("Expected valid=%v for case %d, but got %v", c.valid, i, err)
+		}
+	}
+}
@@ -37,6 +37,14 @@ type Config struct {
 	TLS            bool   `mapstructure:"tls" json:"tls,omitempty"`
 	Location       string `mapstructure:"location" json:"location,omitempty"` // 时区，默认为 Asia/Shanghai
 
+	DisableAgentAutoRegister bool `mapstructure:"disable_agent_auto_register" json:"disable_agent_auto_register,omitempty"` // 禁止使用 AgentSecretKey 自动注册新服务器，只能通过注册令牌注册
+	AgentApprovalRequired    bool `mapstructure:"agent_approval_required" json:"agent_approval_required,omitempty"`         // 使用 AgentSecretKey 注册的新服务器需管理员审核
+
+	AgentMTLS AgentMTLSConfig `mapstructure:"agent_mtls" json:"agent_mtls,omitempty"` // Agent gRPC 双向 TLS 认证
+
+	DBDriver string `mapstructure:"db_driver" json:"db_driver,omitempty"` // 数据库类型：sqlite、postgres、mysql，默认 sqlite
+	DSN      string `mapstructure:"dsn" json:"-"`                         // postgres 与 mysql 的连接字符串，sqlite 使用启动参数中的数据库文件路径
+
 	EnablePlainIPInNotification bool `mapstructure:"enable_plain_ip_in_notification" json:"enable_plain_ip_in_notification,omitempty"` // 通知信息IP不打码
 
 	// IP变更提醒
@@ -48,14 +56,27 @@ type Config struct {
 	IgnoredIPNotificationServerIDs map[uint64]bool `mapstructure:"ignored_ip_notification_server_ids" json:"ignored_ip_notification_server_ids,omitempty"` // [ServerID] -> bool(值为true代表当前ServerID在特定服务器列表内）
 	AvgPingCount                   int             `mapstructure:"avg_ping_count" json:"avg_ping_count,omitempty"`
 	DNSServers                     string          `mapstructure:"dns_servers" json:"dns_servers,omitempty"`
+	SLARetentionDays               int             `mapstructure:"sla_retention_days" json:"sla_retention_days,omitempty"` // 已迁移至 retention.sla_days，仅在其未配置时读取
 
 	CustomCode          string `mapstructure:"custom_code" json:"custom_code,omitempty"`
 	CustomCodeDashboard string `mapstructure:"custom_code_dashboard" json:"custom_code_dashboard,omitempty"`
 
+	Retention RetentionPolicy `mapstructure:"retention" json:"retention,omitempty"` // 数据保留策略
+
 	k        *koanf.Koanf `json:"-"`
 	filePath string       `json:"-"`
 }
 
+// AgentMTLSConfig 开启后在独立端口提供双向 TLS 的 gRPC 服务，使用内置 CA 签发的客户端证书认证 Agent
+type AgentMTLSConfig struct {
+	Enabled        bool     `mapstructure:"enabled" json:"enabled,omitempty"`
+	ListenPort     uint     `mapstructure:"listen_port" json:"listen_port,omitempty"`           // 默认 8009
+	ServerNames    []string `mapstructure:"server_names" json:"server_names,omitempty"`         // 服务端证书包含的域名或 IP，默认使用 InstallHost
+	ClientCertDays uint     `mapstructure:"client_cert_days" json:"client_cert_days,omitempty"` // 客户端证书有效期，默认 365 天
+	CACert         string   `mapstructure:"ca_cert" json:"-"`                                   // 首次开启时自动生成
+	CAKey          string   `mapstructure:"ca_key" json:"-"`
+}
+
 // Read 读取配置文件并应用
 func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	c.k = koanf.New(".")
@@ -75,10 +96,16 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 		}
 	}
 
+	// Save 写入的配置项为小写的字段名（如 dbdriver），文档中的配置项为 mapstructure 标签中的名称（如 db_driver），
+	// 两种写法都需要读取，同时存在时以后者为准
 	err = c.k.Unmarshal("", c)
 	if err != nil {
 		return err
 	}
+	err = c.k.UnmarshalWithConf("", c, koanf.UnmarshalConf{Tag: "mapstructure"})
+	if err != nil {
+		return err
+	}
 	if c.ListenPort == 0 {
 		c.ListenPort = 8008
 	}
@@ -88,6 +115,9 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	if c.Location == "" {
 		c.Location = "Asia/Shanghai"
 	}
+	if c.DBDriver == "" {
+		c.DBDriver = DBDriverSQLite
+	}
 	var userTemplateValid, adminTemplateValid bool
 	for _, v := range frontendTemplates {
 		if !userTemplateValid && v.Path == c.UserTemplate && !v.IsAdmin {
@@ -109,6 +139,18 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	if c.AvgPingCount == 0 {
 		c.AvgPingCount = 2
 	}
+	// 兼容旧版配置中的 sla_retention_days，保存配置时只写入 retention.sla_days
+	if c.Retention.SLADays == 0 {
+		c.Retention.SLADays = c.SLARetentionDays
+	}
+	c.SLARetentionDays = 0
+	c.Retention.FillDefaults()
+	if c.AgentMTLS.ListenPort == 0 {
+		c.AgentMTLS.ListenPort = 8009
+	}
+	if c.AgentMTLS.ClientCertDays == 0 {
+		c.AgentMTLS.ClientCertDays = 365
+	}
 	if c.Cover == 0 {
 		c.Cover = 1
 	}
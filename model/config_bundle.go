@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// ConfigBundleVersion 配置导出格式的版本，格式不兼容时递增
+const ConfigBundleVersion = 1
+
+const (
+	ConfigBundleFormatJSON = "json"
+	ConfigBundleFormatYAML = "yaml"
+)
+
+// ConfigBundle 导出的全部配置项，ID 与导出时保持一致以保留各配置项之间的引用
+type ConfigBundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at,omitempty"`
+
+	Servers                        []*Server                        `json:"servers,omitempty"`
+	ServerGroups                   []*ServerGroup                   `json:"server_groups,omitempty"`
+	ServerGroupServers             []*ServerGroupServer             `json:"server_group_servers,omitempty"`
+	Services                       []*Service                       `json:"services,omitempty"`
+	AlertRules                     []*AlertRule                     `json:"alert_rules,omitempty"`
+	Crons                          []*Cron                          `json:"crons,omitempty"`
+	Notifications                  []*Notification                  `json:"notifications,omitempty"`
+	NotificationGroups             []*NotificationGroup             `json:"notification_groups,omitempty"`
+	NotificationGroupNotifications []*NotificationGroupNotification `json:"notification_group_notifications,omitempty"`
+	DDNSProfiles                   []*DDNSProfile                   `json:"ddns_profiles,omitempty"`
+	NATs                           []*NAT                           `json:"nats,omitempty"`
+}
+
+// MarshalConfigBundle 按指定格式序列化配置，YAML 与 JSON 使用相同的字段名
+func MarshalConfigBundle(b *ConfigBundle, format string) ([]byte, error) {
+	data, err := utils.Json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case ConfigBundleFormatJSON:
+		return data, nil
+	case ConfigBundleFormatYAML:
+		// JSON 本身是合法的 YAML，解析为节点可保留字段顺序
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		resetYAMLStyle(&node)
+		return yaml.Marshal(&node)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// resetYAMLStyle 将 JSON 的行内写法转换为 YAML 的块写法
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
+
+// UnmarshalConfigBundle 解析导出的配置并检查版本
+func UnmarshalConfigBundle(data []byte, format string) (*ConfigBundle, error) {
+	switch format {
+	case ConfigBundleFormatJSON:
+	case ConfigBundleFormatYAML:
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = utils.Json.Marshal(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	var b ConfigBundle
+	if err := utils.Json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	if b.Version == 0 || b.Version > ConfigBundleVersion {
+		return nil, fmt.Errorf("unsupported config version: %d", b.Version)
+	}
+	return &b, nil
+}
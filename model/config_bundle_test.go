@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestConfigBundleRoundTrip(t *testing.T) {
+	b := &ConfigBundle{
+		Version:  ConfigBundleVersion,
+		Servers:  []*Server{{Common: Common{ID: 3}, Name: "s3", DDNSProfiles: []uint64{1}}},
+		Services: []*Service{{Common: Common{ID: 5}, Name: "web", Target: "https://example.com", SkipServers: map[uint64]bool{3: true}}},
+		Crons:    []*Cron{{Common: Common{ID: 1}, Name: "backup", Scheduler: "0 0 3 * * *", Command: "echo 1"}},
+	}
+	for _, format := range []string{ConfigBundleFormatJSON, ConfigBundleFormatYAML} {
+		data, err := MarshalConfigBundle(b, format)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		got, err := UnmarshalConfigBundle(data, format)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if len(got.Servers) != 1 || got.Servers[0].ID != 3 || got.Servers[0].DDNSProfiles[0] != 1 {
+			t.Fatalf("%s: unexpected servers: %+v", format, got.Servers)
+		}
+		if len(got.Services) != 1 || !got.Services[0].SkipServers[3] {
+			t.Fatalf("%s: unexpected services: %+v", format, got.Services)
+		}
+		if len(got.Crons) != 1 || got.Crons[0].Scheduler != "0 0 3 * * *" {
+			t.Fatalf("%s: unexpected crons: %+v", format, got.Crons)
+		}
+	}
+
+	if _, err := UnmarshalConfigBundle([]byte(`{"version": 99}`), ConfigBundleFormatJSON); err == nil {
+		t.Fatalf("Expected error, but got nil")
+	}
+}
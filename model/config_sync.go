@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	ConfigSyncActionCreate = "create"
+	ConfigSyncActionUpdate = "update"
+	ConfigSyncActionDelete = "delete"
+)
+
+const (
+	ConfigSyncKindService           = "service"
+	ConfigSyncKindAlertRule         = "alert_rule"
+	ConfigSyncKindNotificationGroup = "notification_group"
+	ConfigSyncKindCron              = "cron"
+)
+
+// ConfigSyncChange 声明式配置同步中的一项变更
+type ConfigSyncChange struct {
+	Kind   string   `json:"kind"`
+	Action string   `json:"action"`
+	ID     uint64   `json:"id"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"` // 更新时有变化的字段
+}
+
+// ConfigSyncPlan 声明式配置与数据库的差异
+type ConfigSyncPlan struct {
+	Changes []ConfigSyncChange `json:"changes"`
+	Applied bool               `json:"applied"`
+}
+
+// String 返回类似 terraform plan 的文本格式
+func (c ConfigSyncChange) String() string {
+	var sign string
+	switch c.Action {
+	case ConfigSyncActionCreate:
+		sign = "+"
+	case ConfigSyncActionUpdate:
+		sign = "~"
+	case ConfigSyncActionDelete:
+		sign = "-"
+	}
+	s := fmt.Sprintf("%s %s %d %q", sign, c.Kind, c.ID, c.Name)
+	if len(c.Fields) > 0 {
+		s += fmt.Sprintf(" %v", c.Fields)
+	}
+	return s
+}
+
+// ChangedFields 比较两个配置项序列化后的各字段，返回有变化的字段名
+// ID 与创建、更新时间不参与比较
+func ChangedFields(current, desired any, ignore ...string) ([]string, error) {
+	a, err := toFieldMap(current)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toFieldMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	ignore = append(ignore, "id", "created_at", "updated_at")
+
+	var fields []string
+	for k := range a {
+		if _, ok := b[k]; !ok && !slices.Contains(ignore, k) {
+			fields = append(fields, k)
+		}
+	}
+	for k, v := range b {
+		if !slices.Contains(ignore, k) && !reflect.DeepEqual(a[k], v) {
+			fields = append(fields, k)
+		}
+	}
+	slices.Sort(fields)
+	return fields, nil
+}
+
+func toFieldMap(v any) (map[string]any, error) {
+	data, err := utils.Json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := utils.Json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	// 未设置与空列表视为相同
+	for k, v := range m {
+		switch v := v.(type) {
+		case nil:
+			delete(m, k)
+		case []any:
+			if len(v) == 0 {
+				delete(m, k)
+			}
+		case map[string]any:
+			if len(v) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChangedFields(t *testing.T) {
+	current := &Service{Common: Common{ID: 1, CreatedAt: time.Now()}, Name: "web", Target: "https://example.com", Duration: 30}
+	cases := []struct {
+		desired *Service
+		ignore  []string
+		fields  []string
+	}{
+		{&Service{Common: Common{ID: 1}, Name: "web", Target: "https://example.com", Duration: 30}, nil, nil},
+		{&Service{Common: Common{ID: 1}, Name: "web", Target: "https://example.com", Duration: 30, SkipServers: map[uint64]bool{}}, nil, nil},
+		{&Service{Common: Common{ID: 1}, Name: "api", Target: "https://example.com", Duration: 60}, nil, []string{"duration", "name"}},
+		{&Service{Common: Common{ID: 1}, Name: "api", Target: "https://example.com", Duration: 60}, []string{"name"}, []string{"duration"}},
+		{&Service{Common: Common{ID: 1}, Name: "web", Target: "https://example.com", Duration: 30, SkipServers: map[uint64]bool{2: true}}, nil, []string{"skip_servers"}},
+	}
+	for _, c := range cases {
+		fields, err := ChangedFields(current, c.desired, c.ignore...)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if !slices.Equal(fields, c.fields) {
+			t.Fatalf("Expected %v, but got %v", c.fields, fields)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigRead(t *testing.T) {
+	cases := []struct {
+		name string
+		yaml string
+	}{
+		{"documented keys", `
+db_driver: postgres
+dsn: host=127.0.0.1 dbname=nezha
+jwt_secret_key: jwt
+agent_secret_key: agent
+listen_port: 8018
+sla_retention_days: 90
+retention:
+  service_history_days: 14
+agent_mtls:
+  enabled: true
+  listen_port: 9009
+`},
+		// Save 写入的旧版写法
+		{"saved keys", `
+dbdriver: postgres
+dsn: host=127.0.0.1 dbname=nezha
+jwtsecretkey: jwt
+agentsecretkey: agent
+listenport: 8018
+slaretentiondays: 90
+retention:
+  servicehistorydays: 14
+agentmtls:
+  enabled: true
+  listenport: 9009
+`},
+	}
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(c.yaml), 0600); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		var conf Config
+		if err := conf.Read(path, nil); err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if conf.DBDriver != DBDriverPostgres || conf.DSN != "host=127.0.0.1 dbname=nezha" {
+			t.Fatalf("%s: expected postgres dsn, but got %q %q", c.name, conf.DBDriver, conf.DSN)
+		}
+		if conf.JWTSecretKey != "jwt" || conf.AgentSecretKey != "agent" || conf.ListenPort != 8018 {
+			t.Fatalf("%s: expected jwt agent 8018, but got %q %q %d", c.name, conf.JWTSecretKey, conf.AgentSecretKey, conf.ListenPort)
+		}
+		if conf.Retention.ServiceHistoryDays != 14 || conf.Retention.SLADays != 90 {
+			t.Fatalf("%s: expected retention 14 and 90, but got %+v", c.name, conf.Retention)
+		}
+		if !conf.AgentMTLS.Enabled || conf.AgentMTLS.ListenPort != 9009 {
+			t.Fatalf("%s: expected agent mtls on port 9009, but got %+v", c.name, conf.AgentMTLS)
+		}
+	}
+
+	// 同时存在时以文档中的写法为准
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("dbdriver: sqlite\ndb_driver: mysql\njwtsecretkey: jwt\nagentsecretkey: agent\n"), 0600); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var conf Config
+	if err := conf.Read(path, nil); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if conf.DBDriver != DBDriverMySQL {
+		t.Fatalf("Expected %s, but got %s", DBDriverMySQL, conf.DBDriver)
+	}
+}
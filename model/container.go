@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+const (
+	MaxContainers = 256 // 每台服务器保留的容器数量上限
+
+	ContainerStateRunning = "running"
+
+	// ContainerRestartWindow 检测到重启次数增加后，container_restart 规则保持未通过的时长
+	ContainerRestartWindow = 10 * time.Minute
+	// MaxContainerRestartDuration container_restart 规则允许的最大持续时间，需小于重启检测窗口内的采样数
+	MaxContainerRestartDuration = 100
+	// ContainerSnapshotTTL 容器列表超过该时长未更新时无法确认容器状态，container_not_running 规则视为未运行
+	ContainerSnapshotTTL = 5 * time.Minute
+)
+
+// Container 容器的状态与资源占用
+type Container struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Image        string     `json:"image,omitempty"`
+	State        string     `json:"state"`
+	RestartCount uint64     `json:"restart_count"`
+	CPU          float64    `json:"cpu"`                    // CPU 占用百分比
+	MemUsed      uint64     `json:"mem_used"`               // 内存占用，字节
+	MemLimit     uint64     `json:"mem_limit,omitempty"`    // 内存限制，字节，0 为不限制
+	RestartedAt  *time.Time `json:"restarted_at,omitempty"` // 面板最近一次检测到重启次数增加的时间
+}
+
+// ContainerSnapshot 服务器最近一次上报的容器列表
+type ContainerSnapshot struct {
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Containers []Container `json:"containers"`
+}
+
+// Running 容器是否处于运行状态
+func (c *Container) Running() bool {
+	return c.State == ContainerStateRunning
+}
+
+func (s *ContainerSnapshot) find(name string) *Container {
+	for i := range s.Containers {
+		if s.Containers[i].Name == name {
+			return &s.Containers[i]
+		}
+	}
+	return nil
+}
+
+// PB2ContainerSnapshot 转换 Agent 上报的容器列表，与上一次快照对比记录重启次数的增加
+func PB2ContainerSnapshot(prev *ContainerSnapshot, list *pb.ContainerList, now time.Time) *ContainerSnapshot {
+	snapshot := &ContainerSnapshot{UpdatedAt: now}
+	seen := make(map[string]bool)
+	for _, c := range list.GetContainers() {
+		if len(snapshot.Containers) >= MaxContainers {
+			break
+		}
+		if c.GetName() == "" || seen[c.GetName()] {
+			continue
+		}
+		seen[c.GetName()] = true
+
+		container := Container{
+			ID:           c.GetId(),
+			Name:         c.GetName(),
+			Image:        c.GetImage(),
+			State:        c.GetState(),
+			RestartCount: c.GetRestartCount(),
+			CPU:          c.GetCpu(),
+			MemUsed:      c.GetMemUsed(),
+			MemLimit:     c.GetMemLimit(),
+		}
+		if prev != nil {
+			if p := prev.find(container.Name); p != nil {
+				container.RestartedAt = p.RestartedAt
+				if container.RestartCount > p.RestartCount {
+					container.RestartedAt = &now
+				}
+			}
+		}
+		snapshot.Containers = append(snapshot.Containers, container)
+	}
+	return snapshot
+}
+
+// containerNotRunning 指定容器不存在、未运行或容器列表已过期时返回 true，name 为空时检查全部容器
+func containerNotRunning(s *ContainerSnapshot, name string, now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	if now.Sub(s.UpdatedAt) > ContainerSnapshotTTL {
+		return true
+	}
+	if name != "" {
+		c := s.find(name)
+		return c == nil || !c.Running()
+	}
+	for i := range s.Containers {
+		if !s.Containers[i].Running() {
+			return true
+		}
+	}
+	return false
+}
+
+// containerRestarted 指定容器近期重启次数有增加时返回 true，name 为空时检查全部容器
+func containerRestarted(s *ContainerSnapshot, name string, now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	for i := range s.Containers {
+		c := &s.Containers[i]
+		if name != "" && c.Name != name {
+			continue
+		}
+		if c.RestartedAt != nil && now.Sub(*c.RestartedAt) < ContainerRestartWindow {
+			return true
+		}
+	}
+	return false
+}
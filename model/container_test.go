@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+func TestPB2ContainerSnapshot(t *testing.T) {
+	now := time.Now()
+	first := PB2ContainerSnapshot(nil, &pb.ContainerList{Containers: []*pb.Container{
+		{Name: "web", State: "running", RestartCount: 1},
+		{Name: "db", State: "running"},
+		{Name: "web", State: "exited"}, // 重复的容器名
+		{Name: ""},
+	}}, now)
+	if len(first.Containers) != 2 {
+		t.Fatalf("Expected 2 containers, but got %d", len(first.Containers))
+	}
+	if first.Containers[0].RestartedAt != nil {
+		t.Fatalf("Expected no restart on first snapshot, but got %v", first.Containers[0].RestartedAt)
+	}
+
+	later := now.Add(time.Minute)
+	second := PB2ContainerSnapshot(first, &pb.ContainerList{Containers: []*pb.Container{
+		{Name: "web", State: "running", RestartCount: 2},
+		{Name: "db", State: "running"},
+	}}, later)
+	if second.Containers[0].RestartedAt == nil || !second.Containers[0].RestartedAt.Equal(later) {
+		t.Fatalf("Expected web restarted at %v, but got %v", later, second.Containers[0].RestartedAt)
+	}
+	if second.Containers[1].RestartedAt != nil {
+		t.Fatalf("Expected db not restarted, but got %v", second.Containers[1].RestartedAt)
+	}
+
+	third := PB2ContainerSnapshot(second, &pb.ContainerList{Containers: []*pb.Container{
+		{Name: "web", State: "running", RestartCount: 2},
+	}}, later.Add(time.Minute))
+	if third.Containers[0].RestartedAt == nil || !third.Containers[0].RestartedAt.Equal(later) {
+		t.Fatalf("Expected restart time to be kept, but got %v", third.Containers[0].RestartedAt)
+	}
+}
+
+func TestContainerRules(t *testing.T) {
+	now := time.Now()
+	restarted := now.Add(-time.Minute)
+	server := &Server{
+		Host:  &Host{},
+		State: &HostState{},
+		Containers: &ContainerSnapshot{UpdatedAt: now, Containers: []Container{
+			{Name: "web", State: "running", RestartedAt: &restarted},
+			{Name: "job", State: "exited"},
+		}},
+	}
+	cases := []struct {
+		rule     Rule
+		expected bool
+	}{
+		{Rule{Type: "container_not_running", Container: "web"}, true},
+		{Rule{Type: "container_not_running", Container: "job"}, false},
+		{Rule{Type: "container_not_running", Container: "missing"}, false},
+		{Rule{Type: "container_not_running"}, false},
+		{Rule{Type: "container_restart", Container: "web"}, false},
+		{Rule{Type: "container_restart", Container: "job"}, true},
+	}
+	for _, c := range cases {
+		if got := c.rule.Snapshot(nil, server, nil); got != c.expected {
+			t.Fatalf("Expected %v for %s(%s), but got %v", c.expected, c.rule.Type, c.rule.Container, got)
+		}
+	}
+
+	// 容器列表过期时无法确认容器在运行
+	server.Containers.UpdatedAt = now.Add(-ContainerSnapshotTTL - time.Minute)
+	if (&Rule{Type: "container_not_running", Container: "web"}).Snapshot(nil, server, nil) {
+		t.Fatalf("Expected fail with stale container snapshot")
+	}
+	if !(&Rule{Type: "container_restart", Container: "job"}).Snapshot(nil, server, nil) {
+		t.Fatalf("Expected container_restart to ignore stale container snapshot")
+	}
+
+	// 未上报容器的服务器不触发
+	server.Containers = nil
+	for _, c := range cases {
+		if !c.rule.Snapshot(nil, server, nil) {
+			t.Fatalf("Expected pass without container snapshot for %s(%s)", c.rule.Type, c.rule.Container)
+		}
+	}
+}
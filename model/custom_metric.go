@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+const (
+	MaxCustomMetrics           = 64  // 每台服务器保留的自定义指标数量上限，超出部分丢弃
+	MaxCustomMetricLabels      = 8   // 单个指标的标签数量上限
+	MaxCustomMetricNameLength  = 128 // 指标名与标签名的长度上限
+	MaxCustomMetricLabelLength = 256 // 标签值的长度上限
+)
+
+var customMetricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:.]*$`)
+
+// CustomMetric Agent 上报的自定义指标
+type CustomMetric struct {
+	Name   string            `json:"name"`
+	Value  float64           `json:"value"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Match 指标名相同且包含 selector 中的全部标签
+func (m *CustomMetric) Match(name string, selector map[string]string) bool {
+	if m.Name != name {
+		return false
+	}
+	for k, v := range selector {
+		if m.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回 name{k="v"} 形式的指标标识
+func (m *CustomMetric) String() string {
+	if len(m.Labels) == 0 {
+		return m.Name
+	}
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, m.Labels[k]))
+	}
+	return m.Name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// PB2CustomMetrics 校验并限制 Agent 上报的自定义指标：
+// 丢弃名称或标签不合法、值为 NaN/Inf 的指标，相同名称与标签的指标保留最后一个，总数不超过 MaxCustomMetrics
+func PB2CustomMetrics(metrics []*pb.CustomMetric) []CustomMetric {
+	if len(metrics) == 0 {
+		return nil
+	}
+	var result []CustomMetric
+	index := make(map[string]int)
+	for _, m := range metrics {
+		if !validCustomMetric(m) {
+			continue
+		}
+		cm := CustomMetric{Name: m.GetName(), Value: m.GetValue()}
+		if len(m.GetLabels()) > 0 {
+			cm.Labels = make(map[string]string, len(m.GetLabels()))
+			for k, v := range m.GetLabels() {
+				cm.Labels[k] = v
+			}
+		}
+		key := cm.String()
+		if i, ok := index[key]; ok {
+			result[i] = cm
+			continue
+		}
+		if len(result) >= MaxCustomMetrics {
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, cm)
+	}
+	return result
+}
+
+func validCustomMetric(m *pb.CustomMetric) bool {
+	if len(m.GetName()) > MaxCustomMetricNameLength || !customMetricNameRegex.MatchString(m.GetName()) {
+		return false
+	}
+	if math.IsNaN(m.GetValue()) || math.IsInf(m.GetValue(), 0) {
+		return false
+	}
+	if len(m.GetLabels()) > MaxCustomMetricLabels {
+		return false
+	}
+	for k, v := range m.GetLabels() {
+		if len(k) > MaxCustomMetricNameLength || !customMetricNameRegex.MatchString(k) || len(v) > MaxCustomMetricLabelLength {
+			return false
+		}
+	}
+	return true
+}
+
+// CustomMetricValues 返回匹配指标名与标签的全部指标值
+func (s *HostState) CustomMetricValues(name string, selector map[string]string) []float64 {
+	var values []float64
+	for i := range s.CustomMetrics {
+		if s.CustomMetrics[i].Match(name, selector) {
+			values = append(values, s.CustomMetrics[i].Value)
+		}
+	}
+	return values
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var customMetricPlaceholderRegex = regexp.MustCompile(`#SERVER\.METRIC\.([a-zA-Z_:][a-zA-Z0-9_:.]*)#`)
+
+// replaceCustomMetrics 替换通知模板中的自定义指标：
+// #SERVER.METRIC.<name># 为第一个同名指标的值，#SERVER.CUSTOMMETRICS# 为全部指标，每行一个
+func replaceCustomMetrics(str string, state *HostState, mod func(string) string) string {
+	str = customMetricPlaceholderRegex.ReplaceAllStringFunc(str, func(placeholder string) string {
+		name := customMetricPlaceholderRegex.FindStringSubmatch(placeholder)[1]
+		for i := range state.CustomMetrics {
+			if state.CustomMetrics[i].Name == name {
+				return mod(formatMetricValue(state.CustomMetrics[i].Value))
+			}
+		}
+		return mod("")
+	})
+	if strings.Contains(str, "#SERVER.CUSTOMMETRICS#") {
+		lines := make([]string, 0, len(state.CustomMetrics))
+		for i := range state.CustomMetrics {
+			lines = append(lines, state.CustomMetrics[i].String()+" "+formatMetricValue(state.CustomMetrics[i].Value))
+		}
+		str = strings.ReplaceAll(str, "#SERVER.CUSTOMMETRICS#", mod(strings.Join(lines, "\n")))
+	}
+	return str
+}
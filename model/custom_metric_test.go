@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+func TestPB2CustomMetrics(t *testing.T) {
+	metrics := []*pb.CustomMetric{
+		{Name: "queue_depth", Value: 1, Labels: map[string]string{"queue": "a"}},
+		{Name: "queue_depth", Value: 2, Labels: map[string]string{"queue": "b"}},
+		{Name: "queue_depth", Value: 3, Labels: map[string]string{"queue": "a"}}, // 覆盖第一个
+		{Name: "bad name", Value: 1},
+		{Name: "nan", Value: math.NaN()},
+		{Name: "bad_label", Value: 1, Labels: map[string]string{"bad key": "v"}},
+	}
+	got := PB2CustomMetrics(metrics)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 metrics, but got %d: %+v", len(got), got)
+	}
+	if got[0].Value != 3 || got[1].Value != 2 {
+		t.Fatalf("Expected values 3 and 2, but got %v and %v", got[0].Value, got[1].Value)
+	}
+
+	var many []*pb.CustomMetric
+	for i := 0; i < MaxCustomMetrics*2; i++ {
+		many = append(many, &pb.CustomMetric{Name: fmt.Sprintf("m%d", i), Value: float64(i)})
+	}
+	if got := PB2CustomMetrics(many); len(got) != MaxCustomMetrics {
+		t.Fatalf("Expected %d metrics, but got %d", MaxCustomMetrics, len(got))
+	}
+}
+
+func TestCustomMetricRule(t *testing.T) {
+	server := &Server{
+		Host: &Host{},
+		State: &HostState{CustomMetrics: []CustomMetric{
+			{Name: "queue_depth", Value: 10, Labels: map[string]string{"queue": "a"}},
+			{Name: "queue_depth", Value: 200, Labels: map[string]string{"queue": "b"}},
+		}},
+	}
+	cases := []struct {
+		rule Rule
+		pass bool
+	}{
+		{Rule{Type: "custom_metric", Metric: "queue_depth", Max: 100}, false},
+		{Rule{Type: "custom_metric", Metric: "queue_depth", MetricLabels: map[string]string{"queue": "a"}, Max: 100}, true},
+		{Rule{Type: "custom_metric", Metric: "queue_depth", MetricLabels: map[string]string{"queue": "a"}, Min: 50}, false},
+		{Rule{Type: "custom_metric", Metric: "missing", Max: 1}, true},
+	}
+	for i, c := range cases {
+		if got := c.rule.Snapshot(nil, server, nil); got != c.pass {
+			t.Fatalf("%d: Expected %v, but got %v", i, c.pass, got)
+		}
+	}
+}
+
+func TestReplaceCustomMetrics(t *testing.T) {
+	state := &HostState{CustomMetrics: []CustomMetric{
+		{Name: "queue_depth", Value: 1.5, Labels: map[string]string{"queue": "a"}},
+		{Name: "jobs", Value: 3},
+	}}
+	got := replaceCustomMetrics("#SERVER.METRIC.queue_depth# #SERVER.METRIC.missing#|#SERVER.CUSTOMMETRICS#", state, func(s string) string { return s })
+	expected := "1.5 |queue_depth{queue=\"a\"} 1.5\njobs 3"
+	if got != expected {
+		t.Fatalf("Expected %q, but got %q", expected, got)
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DBDriverSQLite   = "sqlite"
+	DBDriverPostgres = "postgres"
+	DBDriverMySQL    = "mysql"
+)
+
+// TimeCondition 生成时间列与参数比较的查询条件
+// SQLite 中时间以带时区的文本存储，需要经过 datetime() 转换后再比较
+func TimeCondition(db *gorm.DB, column, op string) string {
+	column = db.Statement.Quote(column)
+	if db.Dialector.Name() == DBDriverSQLite {
+		return fmt.Sprintf("datetime(%s) %s datetime(?)", column, op)
+	}
+	return fmt.Sprintf("%s %s ?", column, op)
+}
+
+// LongText 长文本，PostgreSQL 不支持 longtext 类型
+type LongText string
+
+func (LongText) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == DBDriverPostgres {
+		return "text"
+	}
+	return "longtext"
+}
+
+// BinaryIP 以 16 字节存储的 IP 地址，PostgreSQL 不支持 binary 类型
+type BinaryIP []byte
+
+func (BinaryIP) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == DBDriverPostgres {
+		return "bytea"
+	}
+	return "binary(16)"
+}
+
+// ResetIDSequence 写入指定 ID 的记录后，将 PostgreSQL 的自增序列同步到当前最大 ID
+func ResetIDSequence(db *gorm.DB, models ...any) error {
+	if db.Dialector.Name() != DBDriverPostgres {
+		return nil
+	}
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+		if err := db.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE((SELECT MAX(id) FROM "+stmt.Quote(table)+"), 0) + 1, false)", table).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
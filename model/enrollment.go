@@ -0,0 +1,74 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// EnrollmentToken 一次性的 Agent 注册令牌，数据库中只保存令牌的哈希
+type EnrollmentToken struct {
+	Common
+	Name          string     `json:"name"`
+	TokenHash     string     `gorm:"uniqueIndex;size:64" json:"-"`
+	ServerGroupID uint64     `json:"server_group_id,omitempty"` // 注册后加入的服务器分组
+	LabelsRaw     string     `json:"-"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"` // 为空时永不过期
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	ServerID      uint64     `json:"server_id,omitempty"` // 使用该令牌注册的服务器
+
+	Labels map[string]string `gorm:"-" json:"labels,omitempty"` // 注册后设置的服务器标签
+}
+
+func (t *EnrollmentToken) BeforeSave(tx *gorm.DB) error {
+	if data, err := utils.Json.Marshal(t.Labels); err != nil {
+		return err
+	} else {
+		t.LabelsRaw = string(data)
+	}
+	return nil
+}
+
+func (t *EnrollmentToken) AfterFind(tx *gorm.DB) error {
+	if t.LabelsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(t.LabelsRaw), &t.Labels); err != nil {
+			log.Println("NEZHA>> EnrollmentToken.AfterFind:", err)
+		}
+	}
+	return nil
+}
+
+// Usable 令牌未使用且未过期
+func (t *EnrollmentToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// ServerCredential 服务器独立的 Agent 密钥，数据库中只保存密钥的哈希。
+// 吊销后服务器只能使用新的注册令牌重新注册
+type ServerCredential struct {
+	ServerID   uint64     `gorm:"primaryKey" json:"server_id"`
+	SecretHash string     `gorm:"size:64" json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (c *ServerCredential) Revoked() bool {
+	return c.RevokedAt != nil
+}
+
+// Verify 校验 Agent 提交的密钥
+func (c *ServerCredential) Verify(secret string) bool {
+	return !c.Revoked() && subtle.ConstantTimeCompare([]byte(c.SecretHash), []byte(HashAgentSecret(secret))) == 1
+}
+
+// HashAgentSecret 注册令牌与服务器密钥均为随机生成，使用 sha256 即可
+func HashAgentSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,30 @@
+package model
+
+type EnrollmentTokenForm struct {
+	Name          string            `json:"name,omitempty" minLength:"1"`
+	ServerGroupID uint64            `json:"server_group_id,omitempty" validate:"optional"` // 注册后加入的服务器分组
+	Labels        map[string]string `json:"labels,omitempty" validate:"optional"`          // 注册后设置的服务器标签
+	ExpiresIn     uint64            `json:"expires_in,omitempty" validate:"optional"`      // 有效期（秒），0 为永不过期
+}
+
+// EnrollmentTokenResponse 令牌明文只在创建时返回一次
+type EnrollmentTokenResponse struct {
+	ID    uint64 `json:"id,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+// ServerSecretResponse 密钥明文只在生成时返回一次
+type ServerSecretResponse struct {
+	Secret string `json:"secret,omitempty"`
+}
+
+type AgentCertificateForm struct {
+	CSR string `json:"csr,omitempty" validate:"optional"` // PEM 格式的证书请求，为空时由面板生成私钥
+}
+
+// AgentCertificateResponse 面板生成的私钥只在签发时返回一次
+type AgentCertificateResponse struct {
+	Certificate   string `json:"certificate,omitempty"`
+	PrivateKey    string `json:"private_key,omitempty"`
+	CACertificate string `json:"ca_certificate,omitempty"`
+}
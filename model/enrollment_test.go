@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnrollmentTokenUsable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	cases := []struct {
+		token  EnrollmentToken
+		usable bool
+	}{
+		{EnrollmentToken{}, true},
+		{EnrollmentToken{ExpiresAt: &future}, true},
+		{EnrollmentToken{ExpiresAt: &past}, false},
+		{EnrollmentToken{UsedAt: &past}, false},
+	}
+	for i, c := range cases {
+		if c.token.Usable(now) != c.usable {
+			t.Fatalf("%d: Expected %v, but got %v", i, c.usable, !c.usable)
+		}
+	}
+}
+
+func TestServerCredentialVerify(t *testing.T) {
+	cred := ServerCredential{SecretHash: HashAgentSecret("secret")}
+	if !cred.Verify("secret") {
+		t.Fatalf("Expected true, but got false")
+	}
+	if cred.Verify("other") {
+		t.Fatalf("Expected false, but got true")
+	}
+	now := time.Now()
+	cred.RevokedAt = &now
+	if cred.Verify("secret") {
+		t.Fatalf("Expected false after revoke, but got true")
+	}
+}
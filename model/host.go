@@ -35,6 +35,9 @@ type HostState struct {
 	ProcessCount   uint64              `json:"process_count,omitempty"`
 	Temperatures   []SensorTemperature `json:"temperatures,omitempty"`
 	GPU            []float64           `json:"gpu,omitempty"`
+	CustomMetrics  []CustomMetric      `json:"custom_metrics,omitempty"`
+	Disks          []DiskState         `json:"disks,omitempty"`      // 各挂载点使用量
+	Interfaces     []NetInterfaceState `json:"interfaces,omitempty"` // 各网卡速率与错误数
 }
 
 func (s *HostState) PB() *pb.State {
@@ -46,6 +49,15 @@ func (s *HostState) PB() *pb.State {
 		})
 	}
 
+	var cms []*pb.CustomMetric
+	for _, m := range s.CustomMetrics {
+		cms = append(cms, &pb.CustomMetric{
+			Name:   m.Name,
+			Value:  m.Value,
+			Labels: m.Labels,
+		})
+	}
+
 	return &pb.State{
 		Cpu:            s.CPU,
 		MemUsed:        s.MemUsed,
@@ -64,7 +76,22 @@ func (s *HostState) PB() *pb.State {
 		ProcessCount:   s.ProcessCount,
 		Temperatures:   ts,
 		Gpu:            s.GPU,
+		CustomMetrics:  cms,
+		Disks:          diskStates2PB(s.Disks),
+		Interfaces:     netInterfaceStates2PB(s.Interfaces),
+	}
+}
+
+// Filter returns a copy of HostState with fields that require authorization removed.
+func (s *HostState) Filter() *HostState {
+	if s == nil {
+		return nil
 	}
+	state := *s
+	state.CustomMetrics = nil
+	state.Disks = nil
+	state.Interfaces = nil
+	return &state
 }
 
 func PB2State(s *pb.State) HostState {
@@ -94,21 +121,25 @@ func PB2State(s *pb.State) HostState {
 		ProcessCount:   s.GetProcessCount(),
 		Temperatures:   ts,
 		GPU:            s.GetGpu(),
+		CustomMetrics:  PB2CustomMetrics(s.GetCustomMetrics()),
+		Disks:          pb2DiskStates(s.GetDisks()),
+		Interfaces:     pb2NetInterfaceStates(s.GetInterfaces()),
 	}
 }
 
 type Host struct {
-	Platform        string   `json:"platform,omitempty"`
-	PlatformVersion string   `json:"platform_version,omitempty"`
-	CPU             []string `json:"cpu,omitempty"`
-	MemTotal        uint64   `json:"mem_total,omitempty"`
-	DiskTotal       uint64   `json:"disk_total,omitempty"`
-	SwapTotal       uint64   `json:"swap_total,omitempty"`
-	Arch            string   `json:"arch,omitempty"`
-	Virtualization  string   `json:"virtualization,omitempty"`
-	BootTime        uint64   `json:"boot_time,omitempty"`
-	Version         string   `json:"version,omitempty"`
-	GPU             []string `json:"gpu,omitempty"`
+	Platform        string     `json:"platform,omitempty"`
+	PlatformVersion string     `json:"platform_version,omitempty"`
+	CPU             []string   `json:"cpu,omitempty"`
+	MemTotal        uint64     `json:"mem_total,omitempty"`
+	DiskTotal       uint64     `json:"disk_total,omitempty"`
+	SwapTotal       uint64     `json:"swap_total,omitempty"`
+	Arch            string     `json:"arch,omitempty"`
+	Virtualization  string     `json:"virtualization,omitempty"`
+	BootTime        uint64     `json:"boot_time,omitempty"`
+	Version         string     `json:"version,omitempty"`
+	GPU             []string   `json:"gpu,omitempty"`
+	Disks           []DiskInfo `json:"disks,omitempty"` // 各挂载点信息
 }
 
 func (h *Host) PB() *pb.Host {
@@ -124,6 +155,7 @@ func (h *Host) PB() *pb.Host {
 		BootTime:        h.BootTime,
 		Version:         h.Version,
 		Gpu:             h.GPU,
+		Disks:           diskInfos2PB(h.Disks),
 	}
 }
 
@@ -155,6 +187,7 @@ func PB2Host(h *pb.Host) Host {
 		BootTime:        h.GetBootTime(),
 		Version:         h.GetVersion(),
 		GPU:             h.GetGpu(),
+		Disks:           pb2DiskInfos(h.GetDisks()),
 	}
 }
 
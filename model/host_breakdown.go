@@ -0,0 +1,166 @@
+package model
+
+import (
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+const (
+	MaxHostDisks      = 64 // 每台服务器保留的挂载点数量上限
+	MaxHostInterfaces = 64 // 每台服务器保留的网卡数量上限
+)
+
+// DiskInfo 挂载点的静态信息，随主机信息上报
+type DiskInfo struct {
+	Mountpoint  string `json:"mountpoint"`
+	Device      string `json:"device,omitempty"`
+	Fstype      string `json:"fstype,omitempty"`
+	Total       uint64 `json:"total,omitempty"`
+	InodesTotal uint64 `json:"inodes_total,omitempty"`
+}
+
+// DiskState 挂载点的使用量，随状态上报
+type DiskState struct {
+	Mountpoint string `json:"mountpoint"`
+	Used       uint64 `json:"used,omitempty"`
+	InodesUsed uint64 `json:"inodes_used,omitempty"`
+}
+
+// NetInterfaceState 网卡的速率与错误数，均为每秒的值
+type NetInterfaceState struct {
+	Name      string `json:"name"`
+	InSpeed   uint64 `json:"in_speed,omitempty"`
+	OutSpeed  uint64 `json:"out_speed,omitempty"`
+	InErrors  uint64 `json:"in_errors,omitempty"`
+	OutErrors uint64 `json:"out_errors,omitempty"`
+}
+
+// Disk 返回挂载点的静态信息
+func (h *Host) Disk(mountpoint string) *DiskInfo {
+	for i := range h.Disks {
+		if h.Disks[i].Mountpoint == mountpoint {
+			return &h.Disks[i]
+		}
+	}
+	return nil
+}
+
+// diskUsages 返回匹配挂载点的磁盘或 inode 使用率，mountpoint 为空时返回全部挂载点
+func diskUsages(host *Host, state *HostState, mountpoint string, inode bool) []float64 {
+	var values []float64
+	for _, d := range state.Disks {
+		if mountpoint != "" && d.Mountpoint != mountpoint {
+			continue
+		}
+		info := host.Disk(d.Mountpoint)
+		if info == nil {
+			continue
+		}
+		if inode {
+			values = append(values, percentage(d.InodesUsed, info.InodesTotal))
+		} else {
+			values = append(values, percentage(d.Used, info.Total))
+		}
+	}
+	return values
+}
+
+// interfaceValues 返回匹配网卡的指标值，name 为空时返回全部网卡
+func interfaceValues(state *HostState, name string, value func(*NetInterfaceState) uint64) []float64 {
+	var values []float64
+	for i := range state.Interfaces {
+		if name != "" && state.Interfaces[i].Name != name {
+			continue
+		}
+		values = append(values, float64(value(&state.Interfaces[i])))
+	}
+	return values
+}
+
+func pb2DiskInfos(disks []*pb.DiskInfo) []DiskInfo {
+	var result []DiskInfo
+	for _, d := range disks {
+		if len(result) >= MaxHostDisks {
+			break
+		}
+		result = append(result, DiskInfo{
+			Mountpoint:  d.GetMountpoint(),
+			Device:      d.GetDevice(),
+			Fstype:      d.GetFstype(),
+			Total:       d.GetTotal(),
+			InodesTotal: d.GetInodesTotal(),
+		})
+	}
+	return result
+}
+
+func pb2DiskStates(disks []*pb.DiskState) []DiskState {
+	var result []DiskState
+	for _, d := range disks {
+		if len(result) >= MaxHostDisks {
+			break
+		}
+		result = append(result, DiskState{
+			Mountpoint: d.GetMountpoint(),
+			Used:       d.GetUsed(),
+			InodesUsed: d.GetInodesUsed(),
+		})
+	}
+	return result
+}
+
+func pb2NetInterfaceStates(interfaces []*pb.NetInterfaceState) []NetInterfaceState {
+	var result []NetInterfaceState
+	for _, n := range interfaces {
+		if len(result) >= MaxHostInterfaces {
+			break
+		}
+		result = append(result, NetInterfaceState{
+			Name:      n.GetName(),
+			InSpeed:   n.GetInSpeed(),
+			OutSpeed:  n.GetOutSpeed(),
+			InErrors:  n.GetInErrors(),
+			OutErrors: n.GetOutErrors(),
+		})
+	}
+	return result
+}
+
+func diskInfos2PB(disks []DiskInfo) []*pb.DiskInfo {
+	var result []*pb.DiskInfo
+	for _, d := range disks {
+		result = append(result, &pb.DiskInfo{
+			Mountpoint:  d.Mountpoint,
+			Device:      d.Device,
+			Fstype:      d.Fstype,
+			Total:       d.Total,
+			InodesTotal: d.InodesTotal,
+		})
+	}
+	return result
+}
+
+func diskStates2PB(disks []DiskState) []*pb.DiskState {
+	var result []*pb.DiskState
+	for _, d := range disks {
+		result = append(result, &pb.DiskState{
+			Mountpoint: d.Mountpoint,
+			Used:       d.Used,
+			InodesUsed: d.InodesUsed,
+		})
+	}
+	return result
+}
+
+func netInterfaceStates2PB(interfaces []NetInterfaceState) []*pb.NetInterfaceState {
+	var result []*pb.NetInterfaceState
+	for _, n := range interfaces {
+		result = append(result, &pb.NetInterfaceState{
+			Name:      n.Name,
+			InSpeed:   n.InSpeed,
+			OutSpeed:  n.OutSpeed,
+			InErrors:  n.InErrors,
+			OutErrors: n.OutErrors,
+		})
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package model
+
+import "testing"
+
+func TestBreakdownRule(t *testing.T) {
+	server := &Server{
+		Host: &Host{Disks: []DiskInfo{
+			{Mountpoint: "/", Total: 100, InodesTotal: 1000},
+			{Mountpoint: "/var", Total: 100, InodesTotal: 1000},
+		}},
+		State: &HostState{
+			Disks: []DiskState{
+				{Mountpoint: "/", Used: 20, InodesUsed: 100},
+				{Mountpoint: "/var", Used: 95, InodesUsed: 990},
+			},
+			Interfaces: []NetInterfaceState{
+				{Name: "eth0", InSpeed: 100, OutSpeed: 1000, InErrors: 1},
+				{Name: "eth1", InSpeed: 5000, OutSpeed: 10},
+			},
+		},
+	}
+	cases := []struct {
+		rule Rule
+		pass bool
+	}{
+		{Rule{Type: "disk_mountpoint", Mountpoint: "/", Max: 90}, true},
+		{Rule{Type: "disk_mountpoint", Mountpoint: "/var", Max: 90}, false},
+		{Rule{Type: "disk_mountpoint", Max: 90}, false},
+		{Rule{Type: "disk_mountpoint", Mountpoint: "/data", Max: 90}, true},
+		{Rule{Type: "inode_mountpoint", Mountpoint: "/var", Max: 98}, false},
+		{Rule{Type: "net_in_speed_interface", Interface: "eth0", Max: 1000}, true},
+		{Rule{Type: "net_in_speed_interface", Max: 1000}, false},
+		{Rule{Type: "net_out_speed_interface", Interface: "eth1", Min: 100}, false},
+		{Rule{Type: "net_errors_interface", Interface: "eth0", Max: 0.5}, false},
+	}
+	for i, c := range cases {
+		if got := c.rule.Snapshot(nil, server, nil); got != c.pass {
+			t.Fatalf("%d: Expected %v, but got %v", i, c.pass, got)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestHostStateFilter(t *testing.T) {
+	state := &HostState{
+		CPU:           12.5,
+		CustomMetrics: []CustomMetric{{Name: "queue_depth", Value: 3}},
+		Disks:         []DiskState{{Mountpoint: "/data"}},
+		Interfaces:    []NetInterfaceState{{Name: "eth0"}},
+	}
+
+	filtered := state.Filter()
+	if filtered.CPU != state.CPU {
+		t.Fatalf("Expected CPU %v, but got %v", state.CPU, filtered.CPU)
+	}
+	if filtered.CustomMetrics != nil {
+		t.Fatalf("Expected custom metrics to be removed, but got %v", filtered.CustomMetrics)
+	}
+	if filtered.Disks != nil || filtered.Interfaces != nil {
+		t.Fatalf("Expected disks and interfaces to be removed, but got %v and %v", filtered.Disks, filtered.Interfaces)
+	}
+	if len(state.CustomMetrics) != 1 {
+		t.Fatalf("Expected original state to be unchanged, but got %v", state.CustomMetrics)
+	}
+	if (*HostState)(nil).Filter() != nil {
+		t.Fatalf("Expected nil for nil state")
+	}
+}
@@ -32,13 +32,13 @@ type NotificationServerBundle struct {
 
 type Notification struct {
 	Common
-	Name          string `json:"name"`
-	URL           string `json:"url"`
-	RequestMethod uint8  `json:"request_method"`
-	RequestType   uint8  `json:"request_type"`
-	RequestHeader string `json:"request_header" gorm:"type:longtext"`
-	RequestBody   string `json:"request_body" gorm:"type:longtext"`
-	VerifyTLS     *bool  `json:"verify_tls,omitempty"`
+	Name          string   `json:"name"`
+	URL           string   `json:"url"`
+	RequestMethod uint8    `json:"request_method"`
+	RequestType   uint8    `json:"request_type"`
+	RequestHeader LongText `json:"request_header"`
+	RequestBody   LongText `json:"request_body"`
+	VerifyTLS     *bool    `json:"verify_tls,omitempty"`
 }
 
 func (ns *NotificationServerBundle) reqURL(message string) string {
@@ -65,12 +65,12 @@ func (ns *NotificationServerBundle) reqBody(message string) (string, error) {
 	}
 	switch n.RequestType {
 	case NotificationRequestTypeJSON:
-		return ns.replaceParamsInString(n.RequestBody, message, func(msg string) string {
+		return ns.replaceParamsInString(string(n.RequestBody), message, func(msg string) string {
 			msgBytes, _ := utils.Json.Marshal(msg)
 			return string(msgBytes)[1 : len(msgBytes)-1]
 		}), nil
 	case NotificationRequestTypeForm:
-		data, err := utils.GjsonParseStringMap(n.RequestBody)
+		data, err := utils.GjsonParseStringMap(string(n.RequestBody))
 		if err != nil {
 			return "", err
 		}
@@ -98,7 +98,7 @@ func (n *Notification) setRequestHeader(req *http.Request) error {
 	if n.RequestHeader == "" {
 		return nil
 	}
-	m, err := utils.GjsonParseStringMap(n.RequestHeader)
+	m, err := utils.GjsonParseStringMap(string(n.RequestHeader))
 	if err != nil {
 		return err
 	}
@@ -191,6 +191,7 @@ func (ns *NotificationServerBundle) replaceParamsInString(str string, message st
 		str = strings.ReplaceAll(str, "#SERVER.LOAD15#", mod(fmt.Sprintf("%f", ns.Server.State.Load15)))
 		str = strings.ReplaceAll(str, "#SERVER.TCPCONNCOUNT#", mod(fmt.Sprintf("%d", ns.Server.State.TcpConnCount)))
 		str = strings.ReplaceAll(str, "#SERVER.UDPCONNCOUNT#", mod(fmt.Sprintf("%d", ns.Server.State.UdpConnCount)))
+		str = replaceCustomMetrics(str, ns.Server.State, mod)
 
 		var ipv4, ipv6, validIP string
 		ipList := strings.Split(ns.Server.GeoIP.IP.Join(), "/")
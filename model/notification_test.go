@@ -31,8 +31,8 @@ func execCase(t *testing.T, item testSt) {
 		URL:           item.url,
 		RequestMethod: item.reqMethod,
 		RequestType:   item.reqType,
-		RequestBody:   item.body,
-		RequestHeader: item.header,
+		RequestBody:   LongText(item.body),
+		RequestHeader: LongText(item.header),
 	}
 	server := Server{
 		Common:       Common{},
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// PendingServer 开启审核后等待管理员审核的新 Agent，审核通过前不会创建服务器
+type PendingServer struct {
+	Common
+	UUID     string    `gorm:"uniqueIndex;size:191" json:"uuid"`
+	IP       string    `json:"ip,omitempty"`       // 最近一次连接的 IP
+	Rejected bool      `json:"rejected,omitempty"` // 已拒绝的 Agent 连接时将被拦截
+	LastSeen time.Time `json:"last_seen"`
+	HostRaw  string    `json:"-"`
+
+	Host *Host `gorm:"-" json:"host,omitempty"` // Agent 上报的主机信息
+}
+
+func (p *PendingServer) BeforeSave(tx *gorm.DB) error {
+	if data, err := utils.Json.Marshal(p.Host); err != nil {
+		return err
+	} else {
+		p.HostRaw = string(data)
+	}
+	return nil
+}
+
+func (p *PendingServer) AfterFind(tx *gorm.DB) error {
+	if p.HostRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(p.HostRaw), &p.Host); err != nil {
+			log.Println("NEZHA>> PendingServer.AfterFind:", err)
+		}
+	}
+	return nil
+}
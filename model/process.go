@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	DefaultTopProcessesLimit = 10
+	MaxTopProcessesLimit     = 50
+	// TopProcessesNotifyLimit 通知中每个排行最多附带的进程数
+	TopProcessesNotifyLimit = 5
+	// topProcessesNameMaxLen 通知中进程名/命令行的最大长度
+	topProcessesNameMaxLen = 48
+)
+
+// TaskTopProcesses 进程快照任务，序列化后作为 pb.Task.Data 下发，Agent 以 JSON 格式的 TopProcesses 作为结果上报
+type TaskTopProcesses struct {
+	Limit int `json:"limit"` // 每个排行返回的进程数
+}
+
+// ProcessInfo 单个进程的资源占用
+type ProcessInfo struct {
+	PID     int32   `json:"pid"`
+	Name    string  `json:"name"`
+	Cmdline string  `json:"cmdline,omitempty"`
+	User    string  `json:"user,omitempty"`
+	CPU     float64 `json:"cpu"`      // CPU 占用百分比
+	Memory  uint64  `json:"memory"`   // 常驻内存，字节
+	MemPerc float32 `json:"mem_perc"` // 内存占用百分比
+}
+
+// TopProcesses 按 CPU 与内存排序的进程快照
+type TopProcesses struct {
+	ByCPU    []ProcessInfo `json:"by_cpu"`
+	ByMemory []ProcessInfo `json:"by_memory"`
+}
+
+// Truncate 每个排行只保留前 n 个进程
+func (t *TopProcesses) Truncate(n int) {
+	if len(t.ByCPU) > n {
+		t.ByCPU = t.ByCPU[:n]
+	}
+	if len(t.ByMemory) > n {
+		t.ByMemory = t.ByMemory[:n]
+	}
+}
+
+// Summary 生成附加在通知中的进程摘要，每个排行最多 n 个进程
+func (t *TopProcesses) Summary(n int) string {
+	var sb strings.Builder
+	writeList := func(title string, ps []ProcessInfo) {
+		if len(ps) == 0 {
+			return
+		}
+		sb.WriteString(title)
+		sb.WriteString(":\n")
+		for i, p := range ps {
+			if i >= n {
+				break
+			}
+			fmt.Fprintf(&sb, "%d %s CPU %.1f%% MEM %.1fMiB\n", p.PID, truncateString(p.displayName(), topProcessesNameMaxLen),
+				p.CPU, float64(p.Memory)/1024/1024)
+		}
+	}
+	writeList("Top CPU", t.ByCPU)
+	writeList("Top Memory", t.ByMemory)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (p *ProcessInfo) displayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Cmdline
+}
+
+func truncateString(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// AlertEvent 报警事件，记录报警规则在某台服务器上的一次触发与恢复
+type AlertEvent struct {
+	Common
+	AlertRuleID  uint64     `gorm:"index" json:"alert_rule_id"`
+	ServerID     uint64     `gorm:"index" json:"server_id"`
+	Message      string     `json:"message"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	ProcessesRaw string     `json:"-"`
+
+	Processes *TopProcesses `gorm:"-" json:"processes,omitempty"` // 触发时采集的进程快照
+}
+
+func (e *AlertEvent) BeforeSave(tx *gorm.DB) error {
+	if e.Processes == nil {
+		e.ProcessesRaw = ""
+		return nil
+	}
+	if data, err := utils.Json.Marshal(e.Processes); err != nil {
+		return err
+	} else {
+		e.ProcessesRaw = string(data)
+	}
+	return nil
+}
+
+func (e *AlertEvent) AfterFind(tx *gorm.DB) error {
+	if e.ProcessesRaw == "" {
+		return nil
+	}
+	if err := utils.Json.Unmarshal([]byte(e.ProcessesRaw), &e.Processes); err != nil {
+		log.Println("NEZHA>> AlertEvent.AfterFind:", err)
+	}
+	return nil
+}
+
+// NeedsTopProcesses 报警规则是否包含资源占用类检查，触发时需要附带进程快照
+func (r *AlertRule) NeedsTopProcesses() bool {
+	for _, rule := range r.Rules {
+		switch rule.Type {
+		case "cpu", "memory", "swap", "load1", "load5", "load15", "process_count":
+			return true
+		}
+	}
+	return false
+}
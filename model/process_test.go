@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTopProcessesSummary(t *testing.T) {
+	tp := &TopProcesses{
+		ByCPU: []ProcessInfo{
+			{PID: 1, Name: "nginx", CPU: 88.5, Memory: 100 << 20},
+			{PID: 2, Cmdline: "/usr/bin/" + strings.Repeat("x", 100), CPU: 10},
+			{PID: 3, Name: "sshd", CPU: 1},
+		},
+		ByMemory: []ProcessInfo{
+			{PID: 4, Name: "mysqld", Memory: 2 << 30},
+		},
+	}
+
+	summary := tp.Summary(2)
+	lines := strings.Split(summary, "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, but got %d: %q", len(lines), summary)
+	}
+	if lines[1] != "1 nginx CPU 88.5% MEM 100.0MiB" {
+		t.Fatalf("Expected nginx line, but got %q", lines[1])
+	}
+	if strings.Contains(summary, "sshd") {
+		t.Fatalf("Expected sshd to be truncated, but got %q", summary)
+	}
+	if len([]rune(strings.Fields(lines[2])[1])) != topProcessesNameMaxLen {
+		t.Fatalf("Expected long cmdline to be truncated to %d, but got %q", topProcessesNameMaxLen, lines[2])
+	}
+
+	tp.Truncate(1)
+	if len(tp.ByCPU) != 1 || len(tp.ByMemory) != 1 {
+		t.Fatalf("Expected 1 process per ranking, but got %d and %d", len(tp.ByCPU), len(tp.ByMemory))
+	}
+	if (&TopProcesses{}).Summary(5) != "" {
+		t.Fatalf("Expected empty summary for empty snapshot")
+	}
+}
+
+func TestAlertRuleNeedsTopProcesses(t *testing.T) {
+	cases := []struct {
+		types    []string
+		expected bool
+	}{
+		{[]string{"offline"}, false},
+		{[]string{"net_in_speed", "cpu"}, true},
+		{[]string{"memory"}, true},
+		{nil, false},
+	}
+	for _, c := range cases {
+		r := &AlertRule{}
+		for _, typ := range c.types {
+			r.Rules = append(r.Rules, &Rule{Type: typ})
+		}
+		if r.NeedsTopProcesses() != c.expected {
+			t.Fatalf("Expected %v for %v, but got %v", c.expected, c.types, !c.expected)
+		}
+	}
+}
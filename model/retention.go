@@ -0,0 +1,61 @@
+package model
+
+import "fmt"
+
+const (
+	RetentionClassServiceHistory = "service_history"
+	RetentionClassPingHistory    = "ping_history"
+	RetentionClassTransfer       = "transfer"
+	RetentionClassTraceroute     = "traceroute"
+	RetentionClassSLA            = "sla"
+	RetentionClassOrphan         = "orphan"
+)
+
+// RetentionPolicy 各类数据的保留天数
+type RetentionPolicy struct {
+	ServiceHistoryDays int `mapstructure:"service_history_days" json:"service_history_days,omitempty"` // 服务监控汇总记录（server_id = 0），用于可用性展示，默认 30 天
+	PingHistoryDays    int `mapstructure:"ping_history_days" json:"ping_history_days,omitempty"`       // 各服务器上报的网络监控记录，数据量较大，默认 1 天
+	TransferDays       int `mapstructure:"transfer_days" json:"transfer_days,omitempty"`               // 流量记录的最少保留天数，流量报警规则统计周期内的记录会保留更久，为 0 时只保留统计周期内的记录
+	TracerouteDays     int `mapstructure:"traceroute_days" json:"traceroute_days,omitempty"`           // 路由追踪记录，默认 7 天
+	SLADays            int `mapstructure:"sla_days" json:"sla_days,omitempty"`                         // 服务监控每日统计与故障记录，可被服务监控单独配置覆盖，默认 730 天
+}
+
+// RetentionDryRunItem 一条清理策略将要删除的记录数
+type RetentionDryRunItem struct {
+	Class string `json:"class"`
+	Table string `json:"table"`
+	Days  int    `json:"days"` // 策略对应的保留天数，为 0 时表示清理无效记录或由其他规则计算
+	Rows  int64  `json:"rows"`
+}
+
+// FillDefaults 为未配置的保留天数填充默认值
+func (p *RetentionPolicy) FillDefaults() {
+	if p.ServiceHistoryDays == 0 {
+		p.ServiceHistoryDays = 30
+	}
+	if p.PingHistoryDays == 0 {
+		p.PingHistoryDays = 1
+	}
+	if p.TracerouteDays == 0 {
+		p.TracerouteDays = 7
+	}
+	if p.SLADays == 0 {
+		p.SLADays = DefaultSLARetentionDays
+	}
+}
+
+// Validate 检查配置是否合法
+func (p *RetentionPolicy) Validate() error {
+	for name, days := range map[string]int{
+		"service_history_days": p.ServiceHistoryDays,
+		"ping_history_days":    p.PingHistoryDays,
+		"transfer_days":        p.TransferDays,
+		"traceroute_days":      p.TracerouteDays,
+		"sla_days":             p.SLADays,
+	} {
+		if days < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	return nil
+}
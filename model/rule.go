@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/nezhahq/nezha/pkg/utils"
 )
@@ -22,16 +23,24 @@ type NResult struct {
 type Rule struct {
 	// 指标类型，cpu、memory、swap、disk、net_in_speed、net_out_speed
 	// net_all_speed、transfer_in、transfer_out、transfer_all、offline
-	// transfer_in_cycle、transfer_out_cycle、transfer_all_cycle
-	Type          string          `json:"type"`
-	Min           float64         `json:"min,omitempty" validate:"optional"`                                                        // 最小阈值 (百分比、字节 kb ÷ 1024)
-	Max           float64         `json:"max,omitempty" validate:"optional"`                                                        // 最大阈值 (百分比、字节 kb ÷ 1024)
-	CycleStart    *time.Time      `json:"cycle_start,omitempty" validate:"optional"`                                                // 流量统计的开始时间
-	CycleInterval uint64          `json:"cycle_interval,omitempty" validate:"optional"`                                             // 流量统计周期
-	CycleUnit     string          `json:"cycle_unit,omitempty" enums:"hour,day,week,month,year" validate:"optional" default:"hour"` // 流量统计周期单位，默认hour,可选(hour, day, week, month, year)
-	Duration      uint64          `json:"duration,omitempty" validate:"optional"`                                                   // 持续时间 (秒)
-	Cover         uint64          `json:"cover"`                                                                                    // 覆盖范围 RuleCoverAll/IgnoreAll
-	Ignore        map[uint64]bool `json:"ignore,omitempty" validate:"optional"`                                                     // 覆盖范围的排除
+	// transfer_in_cycle、transfer_out_cycle、transfer_all_cycle、custom_metric
+	// disk_mountpoint、inode_mountpoint、net_in_speed_interface、net_out_speed_interface、net_errors_interface
+	// container_not_running、container_restart、systemd_unit
+	Type          string            `json:"type"`
+	Metric        string            `json:"metric,omitempty" validate:"optional"`                                                     // custom_metric 的指标名
+	MetricLabels  map[string]string `json:"metric_labels,omitempty" validate:"optional"`                                              // custom_metric 的标签筛选，匹配多个指标时任一超出阈值即触发
+	Mountpoint    string            `json:"mountpoint,omitempty" validate:"optional"`                                                 // *_mountpoint 的挂载点，为空时任一挂载点超出阈值即触发
+	Interface     string            `json:"interface,omitempty" validate:"optional"`                                                  // *_interface 的网卡名，为空时任一网卡超出阈值即触发
+	Container     string            `json:"container,omitempty" validate:"optional"`                                                  // container_* 的容器名，为空时任一容器不符合即触发
+	Unit          string            `json:"unit,omitempty" validate:"optional"`                                                       // systemd_unit 的单元名，为空时任一监控的单元失败或停止即触发
+	Min           float64           `json:"min,omitempty" validate:"optional"`                                                        // 最小阈值 (百分比、字节 kb ÷ 1024)
+	Max           float64           `json:"max,omitempty" validate:"optional"`                                                        // 最大阈值 (百分比、字节 kb ÷ 1024)
+	CycleStart    *time.Time        `json:"cycle_start,omitempty" validate:"optional"`                                                // 流量统计的开始时间
+	CycleInterval uint64            `json:"cycle_interval,omitempty" validate:"optional"`                                             // 流量统计周期
+	CycleUnit     string            `json:"cycle_unit,omitempty" enums:"hour,day,week,month,year" validate:"optional" default:"hour"` // 流量统计周期单位，默认hour,可选(hour, day, week, month, year)
+	Duration      uint64            `json:"duration,omitempty" validate:"optional"`                                                   // 持续时间 (秒)
+	Cover         uint64            `json:"cover"`                                                                                    // 覆盖范围 RuleCoverAll/IgnoreAll
+	Ignore        map[uint64]bool   `json:"ignore,omitempty" validate:"optional"`                                                     // 覆盖范围的排除
 
 	// 只作为缓存使用，记录下次该检测的时间
 	NextTransferAt  map[uint64]time.Time `json:"-"`
@@ -62,6 +71,9 @@ func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server,
 	}
 
 	var src float64
+	// 按挂载点、网卡或标签匹配多个值的规则，任一值超出阈值即不通过
+	var values []float64
+	var multiple bool
 
 	switch u.Type {
 	case "cpu":
@@ -96,21 +108,21 @@ func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server,
 		src = float64(utils.Uint64SubInt64(server.State.NetInTransfer, server.PrevTransferInSnapshot))
 		if u.CycleInterval != 0 {
 			var res NResult
-			db.Model(&Transfer{}).Select("SUM(`in`) AS n").Where("datetime(`created_at`) >= datetime(?) AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
+			db.Model(&Transfer{}).Select("SUM(?) AS n", clause.Column{Name: "in"}).Where(TimeCondition(db, "created_at", ">=")+" AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
 			src += float64(res.N)
 		}
 	case "transfer_out_cycle":
 		src = float64(utils.Uint64SubInt64(server.State.NetOutTransfer, server.PrevTransferOutSnapshot))
 		if u.CycleInterval != 0 {
 			var res NResult
-			db.Model(&Transfer{}).Select("SUM(`out`) AS n").Where("datetime(`created_at`) >= datetime(?) AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
+			db.Model(&Transfer{}).Select("SUM(?) AS n", clause.Column{Name: "out"}).Where(TimeCondition(db, "created_at", ">=")+" AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
 			src += float64(res.N)
 		}
 	case "transfer_all_cycle":
 		src = float64(utils.Uint64SubInt64(server.State.NetOutTransfer, server.PrevTransferOutSnapshot) + utils.Uint64SubInt64(server.State.NetInTransfer, server.PrevTransferInSnapshot))
 		if u.CycleInterval != 0 {
 			var res NResult
-			db.Model(&Transfer{}).Select("SUM(`in`+`out`) AS n").Where("datetime(`created_at`) >= datetime(?) AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
+			db.Model(&Transfer{}).Select("SUM(? + ?) AS n", clause.Column{Name: "in"}, clause.Column{Name: "out"}).Where(TimeCondition(db, "created_at", ">=")+" AND server_id = ?", u.GetTransferDurationStart().UTC(), server.ID).Scan(&res)
 			src += float64(res.N)
 		}
 	case "load1":
@@ -125,6 +137,36 @@ func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server,
 		src = float64(server.State.UdpConnCount)
 	case "process_count":
 		src = float64(server.State.ProcessCount)
+	case "custom_metric":
+		multiple = true
+		values = server.State.CustomMetricValues(u.Metric, u.MetricLabels)
+	case "disk_mountpoint":
+		multiple = true
+		values = diskUsages(server.Host, server.State, u.Mountpoint, false)
+	case "inode_mountpoint":
+		multiple = true
+		values = diskUsages(server.Host, server.State, u.Mountpoint, true)
+	case "net_in_speed_interface":
+		multiple = true
+		values = interfaceValues(server.State, u.Interface, func(n *NetInterfaceState) uint64 { return n.InSpeed })
+	case "net_out_speed_interface":
+		multiple = true
+		values = interfaceValues(server.State, u.Interface, func(n *NetInterfaceState) uint64 { return n.OutSpeed })
+	case "net_errors_interface":
+		multiple = true
+		values = interfaceValues(server.State, u.Interface, func(n *NetInterfaceState) uint64 { return n.InErrors + n.OutErrors })
+	case "container_not_running":
+		if containerNotRunning(server.Containers, u.Container, time.Now()) {
+			return false
+		}
+	case "container_restart":
+		if containerRestarted(server.Containers, u.Container, time.Now()) {
+			return false
+		}
+	case "systemd_unit":
+		if systemdUnitDown(server.SystemdUnits, u.Unit) {
+			return false
+		}
 	case "temperature_max":
 		var temp []float64
 		if server.State.Temperatures != nil {
@@ -137,6 +179,16 @@ func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server,
 		}
 	}
 
+	if multiple {
+		if len(values) == 0 {
+			return true
+		}
+		if u.Min > 0 && slices.Min(values) < u.Min {
+			return false
+		}
+		src = slices.Max(values)
+	}
+
 	// 循环区间流量检测 · 更新下次需要检测时间
 	if u.IsTransferDurationRule() {
 		seconds := 1800 * ((u.Max - src) / u.Max)
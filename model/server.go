@@ -21,8 +21,16 @@ type Server struct {
 	HideForGuest    bool   `json:"hide_for_guest,omitempty"` // 对游客隐藏
 	EnableDDNS      bool   `json:"enable_ddns,omitempty"`    // 启用DDNS
 	DDNSProfilesRaw string `gorm:"default:'[]';column:ddns_profiles_raw" json:"-"`
+	LabelsRaw       string `json:"-"`
+	WatchedUnitsRaw string `json:"-"`
+	AgentConfigRaw  string `json:"-"`
 
-	DDNSProfiles []uint64 `gorm:"-" json:"ddns_profiles,omitempty" validate:"optional"` // DDNS配置
+	AgentConfigVersion uint64 `json:"agent_config_version,omitempty"` // Agent 已确认应用的配置版本
+
+	DDNSProfiles []uint64          `gorm:"-" json:"ddns_profiles,omitempty" validate:"optional"` // DDNS配置
+	Labels       map[string]string `gorm:"-" json:"labels,omitempty" validate:"optional"`        // 标签
+	WatchedUnits []string          `gorm:"-" json:"watched_units,omitempty" validate:"optional"` // 监控的 systemd 单元
+	AgentConfig  *AgentConfig      `gorm:"-" json:"agent_config,omitempty" validate:"optional"`  // 服务器自身的 Agent 配置
 
 	Host       *Host      `gorm:"-" json:"host,omitempty"`
 	State      *HostState `gorm:"-" json:"state,omitempty"`
@@ -30,6 +38,9 @@ type Server struct {
 	LastActive time.Time  `gorm:"-" json:"last_active,omitempty"`
 
 	TaskStream pb.NezhaService_RequestTaskServer `gorm:"-" json:"-"`
+	Containers *ContainerSnapshot                `gorm:"-" json:"-"` // 最近一次上报的容器列表，通过单独的接口获取
+
+	SystemdUnits []SystemdUnit `gorm:"-" json:"systemd_units,omitempty"` // Agent 上报的监控单元状态
 
 	PrevTransferInSnapshot  int64 `gorm:"-" json:"-"` // 上次数据点时的入站使用量
 	PrevTransferOutSnapshot int64 `gorm:"-" json:"-"` // 上次数据点时的出站使用量
@@ -41,10 +52,33 @@ func (s *Server) CopyFromRunningServer(old *Server) {
 	s.GeoIP = old.GeoIP
 	s.LastActive = old.LastActive
 	s.TaskStream = old.TaskStream
+	s.Containers = old.Containers
+	s.SystemdUnits = old.SystemdUnits
 	s.PrevTransferInSnapshot = old.PrevTransferInSnapshot
 	s.PrevTransferOutSnapshot = old.PrevTransferOutSnapshot
 }
 
+func (s *Server) BeforeSave(tx *gorm.DB) error {
+	if data, err := utils.Json.Marshal(s.Labels); err != nil {
+		return err
+	} else {
+		s.LabelsRaw = string(data)
+	}
+	if data, err := utils.Json.Marshal(s.WatchedUnits); err != nil {
+		return err
+	} else {
+		s.WatchedUnitsRaw = string(data)
+	}
+	if s.AgentConfig.IsEmpty() {
+		s.AgentConfigRaw = ""
+	} else if data, err := utils.Json.Marshal(s.AgentConfig); err != nil {
+		return err
+	} else {
+		s.AgentConfigRaw = string(data)
+	}
+	return nil
+}
+
 func (s *Server) AfterFind(tx *gorm.DB) error {
 	if s.DDNSProfilesRaw != "" {
 		if err := utils.Json.Unmarshal([]byte(s.DDNSProfilesRaw), &s.DDNSProfiles); err != nil {
@@ -52,5 +86,23 @@ func (s *Server) AfterFind(tx *gorm.DB) error {
 			return nil
 		}
 	}
+	if s.LabelsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(s.LabelsRaw), &s.Labels); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.WatchedUnitsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(s.WatchedUnitsRaw), &s.WatchedUnits); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.AgentConfigRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(s.AgentConfigRaw), &s.AgentConfig); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
 	return nil
 }
@@ -27,6 +27,9 @@ type ServerForm struct {
 	HideForGuest bool     `json:"hide_for_guest,omitempty" validate:"optional"`         // 对游客隐藏
 	EnableDDNS   bool     `json:"enable_ddns,omitempty" validate:"optional"`            // 启用DDNS
 	DDNSProfiles []uint64 `gorm:"-" json:"ddns_profiles,omitempty" validate:"optional"` // DDNS配置
+
+	Labels       map[string]string `json:"labels,omitempty" validate:"optional"`        // 标签
+	WatchedUnits []string          `json:"watched_units,omitempty" validate:"optional"` // 监控的 systemd 单元，如 nginx.service
 }
 
 type ForceUpdateResponse struct {
@@ -34,3 +37,10 @@ type ForceUpdateResponse struct {
 	Failure []uint64 `json:"failure,omitempty" validate:"optional"`
 	Offline []uint64 `json:"offline,omitempty" validate:"optional"`
 }
+
+type AgentConfigResponse struct {
+	Config         *AgentConfig `json:"config,omitempty"`          // 服务器自身的配置
+	Effective      *AgentConfig `json:"effective"`                 // 合并分组配置后生效的配置
+	Version        uint64       `json:"version"`                   // 生效配置的版本
+	AppliedVersion uint64       `json:"applied_version,omitempty"` // Agent 已确认应用的版本
+}
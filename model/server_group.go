@@ -1,7 +1,38 @@
 package model
 
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
 type ServerGroup struct {
 	Common
 
-	Name string `json:"name"`
+	Name           string `json:"name"`
+	AgentConfigRaw string `json:"-"`
+
+	AgentConfig *AgentConfig `gorm:"-" json:"agent_config,omitempty"` // 分组内服务器的 Agent 配置
+}
+
+func (sg *ServerGroup) BeforeSave(tx *gorm.DB) error {
+	if sg.AgentConfig.IsEmpty() {
+		sg.AgentConfigRaw = ""
+	} else if data, err := utils.Json.Marshal(sg.AgentConfig); err != nil {
+		return err
+	} else {
+		sg.AgentConfigRaw = string(data)
+	}
+	return nil
+}
+
+func (sg *ServerGroup) AfterFind(tx *gorm.DB) error {
+	if sg.AgentConfigRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(sg.AgentConfigRaw), &sg.AgentConfig); err != nil {
+			log.Println("NEZHA>> ServerGroup.AfterFind:", err)
+		}
+	}
+	return nil
 }
@@ -3,6 +3,8 @@ package model
 import (
 	"fmt"
 	"log"
+	"slices"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
@@ -24,6 +26,13 @@ const (
 	TaskTypeNAT
 	TaskTypeReportHostInfoDeprecated
 	TaskTypeFM
+	TaskTypeDNS
+	TaskTypeHeartbeat
+	TaskTypeTLS
+	TaskTypeTraceroute
+	TaskTypeTopProcesses
+	TaskTypeSystemdUnits
+	TaskTypeAgentConfig
 )
 
 type TerminalTask struct {
@@ -44,6 +53,12 @@ const (
 	ServiceCoverIgnoreAll
 )
 
+const (
+	ServiceDispatchAll        = iota // 每轮下发给全部符合 Cover 规则的在线服务器
+	ServiceDispatchRoundRobin        // 每轮轮流下发给一台符合 Cover 规则的在线服务器
+	ServiceDispatchPinned            // 每轮下发给 PinnedServers 中的在线服务器，忽略 Cover 规则
+)
+
 type Service struct {
 	Common
 	Name                string `json:"name"`
@@ -54,29 +69,96 @@ type Service struct {
 	Notify              bool   `json:"notify,omitempty"`
 	NotificationGroupID uint64 `json:"notification_group_id"` // 当前服务监控所属的通知组 ID
 	Cover               uint8  `json:"cover"`
+	DispatchMode        uint8  `json:"dispatch_mode"` // 任务下发模式
 
 	EnableTriggerTask      bool   `gorm:"default: false" json:"enable_trigger_task,omitempty"`
 	EnableShowInService    bool   `gorm:"default: false" json:"enable_show_in_service,omitempty"`
 	FailTriggerTasksRaw    string `gorm:"default:'[]'" json:"-"`
 	RecoverTriggerTasksRaw string `gorm:"default:'[]'" json:"-"`
+	HTTPOptionsRaw         string `gorm:"default:'{}'" json:"-"`
+	DNSOptionsRaw          string `gorm:"default:'{}'" json:"-"`
+	TLSOptionsRaw          string `gorm:"default:'{}'" json:"-"`
+	PinnedServersRaw       string `gorm:"default:'[]'" json:"-"`
 
 	FailTriggerTasks    []uint64 `gorm:"-" json:"fail_trigger_tasks"`    // 失败时执行的触发任务id
 	RecoverTriggerTasks []uint64 `gorm:"-" json:"recover_trigger_tasks"` // 恢复时执行的触发任务id
 
+	HTTPOptions *ServiceHTTPOptions `gorm:"-" json:"http_options,omitempty"` // HTTP 监控的请求参数与断言
+	DNSOptions  *ServiceDNSOptions  `gorm:"-" json:"dns_options,omitempty"`  // DNS 监控的查询参数与期望值
+	TLSOptions  *ServiceTLSOptions  `gorm:"-" json:"tls_options,omitempty"`  // TLS 证书监控参数
+
+	HeartbeatToken string `json:"heartbeat_token,omitempty"` // 心跳监控的上报密钥
+	HeartbeatGrace uint64 `json:"heartbeat_grace,omitempty"` // 心跳监控超时的宽限时间（秒）
+
+	QuorumProbes   uint64 `json:"quorum_probes,omitempty"`   // 每轮参与监测的服务器数量，为 0 时使用全部符合条件的服务器
+	QuorumFailures uint64 `json:"quorum_failures,omitempty"` // 至少该数量的监测点失败时才判定为故障，为 0 时按单次上报结果判定
+
 	MinLatency    float32 `json:"min_latency"`
 	MaxLatency    float32 `json:"max_latency"`
 	LatencyNotify bool    `json:"latency_notify,omitempty"`
+	MaxLoss       float32 `json:"max_loss,omitempty"`   // ping 监控丢包率报警阈值（百分比），为 0 时不检查
+	MaxJitter     float32 `json:"max_jitter,omitempty"` // ping 监控延迟抖动报警阈值（毫秒），为 0 时不检查
 
-	SkipServers map[uint64]bool `gorm:"-" json:"skip_servers"`
-	CronJobID   cron.EntryID    `gorm:"-" json:"-"`
+	AutoTraceroute bool `json:"auto_traceroute,omitempty"` // ping 监控延迟超过阈值时自动从上报服务器发起路由追踪
+
+	SLARetentionDays uint64 `json:"sla_retention_days,omitempty"` // 每日统计保留天数，为 0 时使用数据保留策略中的配置
+
+	SkipServers   map[uint64]bool `gorm:"-" json:"skip_servers"`
+	PinnedServers []uint64        `gorm:"-" json:"pinned_servers,omitempty"` // 指定下发模式下执行任务的服务器，按顺序选取
+	CronJobID     cron.EntryID    `gorm:"-" json:"-"`
 }
 
 func (m *Service) PB() *pb.Task {
 	return &pb.Task{
 		Id:   m.ID,
 		Type: uint64(m.Type),
-		Data: m.Target,
+		Data: m.taskData(),
+	}
+}
+
+// taskData 返回下发给 Agent 的任务数据，未配置额外参数时保持只下发 Target 以兼容旧版 Agent
+func (m *Service) taskData() string {
+	if m.Type == TaskTypeHTTPGet && !m.HTTPOptions.IsEmpty() {
+		data, err := utils.Json.Marshal(TaskHTTP{URL: m.Target, ServiceHTTPOptions: *m.HTTPOptions})
+		if err != nil {
+			log.Println("NEZHA>> Service.taskData:", err)
+			return m.Target
+		}
+		return string(data)
 	}
+	if m.Type == TaskTypeTLS {
+		task := TaskTLS{Target: m.Target}
+		if m.TLSOptions != nil {
+			task.ServiceTLSOptions = *m.TLSOptions
+		}
+		data, err := utils.Json.Marshal(task)
+		if err != nil {
+			log.Println("NEZHA>> Service.taskData:", err)
+			return m.Target
+		}
+		return string(data)
+	}
+	if m.Type == TaskTypeDNS {
+		task := TaskDNS{Name: m.Target}
+		if m.DNSOptions != nil {
+			task.ServiceDNSOptions = *m.DNSOptions
+		}
+		data, err := utils.Json.Marshal(task)
+		if err != nil {
+			log.Println("NEZHA>> Service.taskData:", err)
+			return m.Target
+		}
+		return string(data)
+	}
+	return m.Target
+}
+
+// SupportsAgent 判断该版本的 Agent 能否执行本监控任务，配置了请求参数或断言的 HTTP 监控需要 TaskHTTPMinAgentVersion 以上的 Agent
+func (m *Service) SupportsAgent(version string) bool {
+	if m.Type == TaskTypeHTTPGet && !m.HTTPOptions.IsEmpty() {
+		return AgentVersionAtLeast(version, TaskHTTPMinAgentVersion)
+	}
+	return true
 }
 
 // CronSpec 返回服务监控请求间隔对应的 cron 表达式
@@ -88,6 +170,11 @@ func (m *Service) CronSpec() string {
 	return fmt.Sprintf("@every %ds", m.Duration)
 }
 
+// HeartbeatTimeout 返回心跳监控判定超时的时长
+func (m *Service) HeartbeatTimeout() time.Duration {
+	return time.Duration(m.Duration+m.HeartbeatGrace) * time.Second
+}
+
 func (m *Service) BeforeSave(tx *gorm.DB) error {
 	if data, err := utils.Json.Marshal(m.SkipServers); err != nil {
 		return err
@@ -104,6 +191,26 @@ func (m *Service) BeforeSave(tx *gorm.DB) error {
 	} else {
 		m.RecoverTriggerTasksRaw = string(data)
 	}
+	if data, err := utils.Json.Marshal(m.HTTPOptions); err != nil {
+		return err
+	} else {
+		m.HTTPOptionsRaw = string(data)
+	}
+	if data, err := utils.Json.Marshal(m.DNSOptions); err != nil {
+		return err
+	} else {
+		m.DNSOptionsRaw = string(data)
+	}
+	if data, err := utils.Json.Marshal(m.TLSOptions); err != nil {
+		return err
+	} else {
+		m.TLSOptionsRaw = string(data)
+	}
+	if data, err := utils.Json.Marshal(m.PinnedServers); err != nil {
+		return err
+	} else {
+		m.PinnedServersRaw = string(data)
+	}
 	return nil
 }
 
@@ -122,10 +229,60 @@ func (m *Service) AfterFind(tx *gorm.DB) error {
 		return err
 	}
 
+	// 加载 HTTP 监控参数
+	if m.HTTPOptionsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(m.HTTPOptionsRaw), &m.HTTPOptions); err != nil {
+			return err
+		}
+		if m.HTTPOptions.IsEmpty() {
+			m.HTTPOptions = nil
+		}
+	}
+
+	// 加载 DNS 监控参数
+	if m.DNSOptionsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(m.DNSOptionsRaw), &m.DNSOptions); err != nil {
+			return err
+		}
+	}
+
+	// 加载 TLS 证书监控参数
+	if m.TLSOptionsRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(m.TLSOptionsRaw), &m.TLSOptions); err != nil {
+			return err
+		}
+	}
+
+	// 加载指定下发的服务器列表
+	if m.PinnedServersRaw != "" {
+		if err := utils.Json.Unmarshal([]byte(m.PinnedServersRaw), &m.PinnedServers); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// IsAgentTask 判断该服务监控是否需要下发给 Agent 执行，心跳监控由被监控方主动上报
+func (m *Service) IsAgentTask() bool {
+	return m.Type != TaskTypeHeartbeat
+}
+
+// CanDispatchTo 判断该服务监控是否可以下发给指定服务器执行
+func (m *Service) CanDispatchTo(serverID uint64) bool {
+	if m.DispatchMode == ServiceDispatchPinned {
+		return slices.Contains(m.PinnedServers, serverID)
+	}
+	// 有些 IPv6 only 开了 NAT64 的机器请求 IPv4 总会出问题，可通过 Cover 规则排除
+	if m.Cover == ServiceCoverAll {
+		return !m.SkipServers[serverID]
+	}
+	return m.SkipServers[serverID]
+}
+
 // IsServiceSentinelNeeded 判断该任务类型是否需要进行服务监控 需要则返回true
 func IsServiceSentinelNeeded(t uint64) bool {
-	return t != TaskTypeCommand && t != TaskTypeTerminalGRPC && t != TaskTypeUpgrade && t != TaskTypeKeepalive
+	return t != TaskTypeCommand && t != TaskTypeTerminalGRPC && t != TaskTypeUpgrade && t != TaskTypeKeepalive &&
+		t != TaskTypeTraceroute && t != TaskTypeTopProcesses && t != TaskTypeSystemdUnits &&
+		t != TaskTypeAgentConfig
 }
@@ -12,12 +12,33 @@ type ServiceForm struct {
 	MinLatency          float32         `json:"min_latency,omitempty" default:"0.0"`
 	MaxLatency          float32         `json:"max_latency,omitempty" default:"0.0"`
 	LatencyNotify       bool            `json:"latency_notify,omitempty" validate:"optional"`
+	MaxLoss             float32         `json:"max_loss,omitempty" default:"0.0" validate:"optional"`
+	MaxJitter           float32         `json:"max_jitter,omitempty" default:"0.0" validate:"optional"`
+	AutoTraceroute      bool            `json:"auto_traceroute,omitempty" validate:"optional"`
+	SLARetentionDays    uint64          `json:"sla_retention_days,omitempty" validate:"optional"`
 	EnableTriggerTask   bool            `json:"enable_trigger_task,omitempty" validate:"optional"`
 	EnableShowInService bool            `json:"enable_show_in_service,omitempty" validate:"optional"`
 	FailTriggerTasks    []uint64        `json:"fail_trigger_tasks,omitempty"`
 	RecoverTriggerTasks []uint64        `json:"recover_trigger_tasks,omitempty"`
 	SkipServers         map[uint64]bool `json:"skip_servers,omitempty"`
+	DispatchMode        uint8           `json:"dispatch_mode,omitempty" validate:"optional"`
+	PinnedServers       []uint64        `json:"pinned_servers,omitempty" validate:"optional"`
 	NotificationGroupID uint64          `json:"notification_group_id,omitempty"`
+
+	HTTPOptions *ServiceHTTPOptions `json:"http_options,omitempty" validate:"optional"`
+	DNSOptions  *ServiceDNSOptions  `json:"dns_options,omitempty" validate:"optional"`
+	TLSOptions  *ServiceTLSOptions  `json:"tls_options,omitempty" validate:"optional"`
+
+	HeartbeatGrace           uint64 `json:"heartbeat_grace,omitempty" validate:"optional"`
+	RegenerateHeartbeatToken bool   `json:"regenerate_heartbeat_token,omitempty" validate:"optional"` // 重新生成心跳监控上报密钥
+
+	QuorumProbes   uint64 `json:"quorum_probes,omitempty" validate:"optional"`
+	QuorumFailures uint64 `json:"quorum_failures,omitempty" validate:"optional"`
+}
+
+// ServiceHeartbeatTokenResponse 服务列表中不返回心跳监控的上报密钥
+type ServiceHeartbeatTokenResponse struct {
+	Token string `json:"token,omitempty"`
 }
 
 type ServiceResponseItem struct {
@@ -29,6 +50,9 @@ type ServiceResponseItem struct {
 	Delay       *[30]float32 `json:"delay,omitempty"`
 	Up          *[30]int     `json:"up,omitempty"`
 	Down        *[30]int     `json:"down,omitempty"`
+
+	TLS       *TLSCertInfo                     `json:"tls,omitempty"`       // TLS 证书监控最近一次获取到的证书信息
+	Locations map[uint64]ServiceLocationStatus `json:"locations,omitempty"` // [server_id] -> 各监测点最近一次的监控结果
 }
 
 func (r ServiceResponseItem) TotalUptime() float32 {
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// ServiceDNSOptions DNS 服务监控的查询参数，Service.Target 为查询的域名
+type ServiceDNSOptions struct {
+	RecordType string   `json:"record_type,omitempty"` // 记录类型，默认 A
+	Resolver   string   `json:"resolver,omitempty"`    // 指定的解析服务器 host:port，为空时使用 Agent 系统配置
+	Expected   []string `json:"expected,omitempty"`    // 期望值，应答中需包含全部期望值，为空时仅要求有应答
+}
+
+// TaskDNS DNS 监控任务，序列化后作为 pb.Task.Data 下发。
+// Agent 只负责查询，查询成功时将 TaskDNSResult 序列化后作为 pb.TaskResult.Data 上报，
+// 应答码与期望值由面板按 ServiceDNSOptions.Evaluate 判定；查询失败时上报失败原因
+type TaskDNS struct {
+	Name string `json:"name"`
+	ServiceDNSOptions
+}
+
+// TaskDNSResult Agent 上报的 DNS 查询结果
+type TaskDNSResult struct {
+	Rcode   string   `json:"rcode"`             // 应答码，如 NOERROR、NXDOMAIN
+	Answers []string `json:"answers,omitempty"` // 与查询类型一致的应答记录值
+}
+
+// QueryType 返回查询类型对应的 dns 类型值
+func (o *ServiceDNSOptions) QueryType() (uint16, error) {
+	if o.RecordType == "" {
+		return dns.TypeA, nil
+	}
+	t, ok := dns.StringToType[strings.ToUpper(o.RecordType)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported record type: %s", o.RecordType)
+	}
+	return t, nil
+}
+
+// ResolverAddr 返回带端口的解析服务器地址
+func (o *ServiceDNSOptions) ResolverAddr() string {
+	if o.Resolver == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(o.Resolver); err == nil {
+		return o.Resolver
+	}
+	return net.JoinHostPort(strings.Trim(o.Resolver, "[]"), "53")
+}
+
+// Validate 检查配置是否合法
+func (o *ServiceDNSOptions) Validate() error {
+	if _, err := o.QueryType(); err != nil {
+		return err
+	}
+	if addr := o.ResolverAddr(); addr != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			return errors.New("resolver host is empty")
+		}
+	}
+	return nil
+}
+
+// Evaluate 判定 Agent 上报的查询结果，应答码非 NOERROR 或未通过 Verify 时返回失败原因
+func (o *ServiceDNSOptions) Evaluate(r *TaskDNSResult) error {
+	if r.Rcode != "" && !strings.EqualFold(r.Rcode, dns.RcodeToString[dns.RcodeSuccess]) {
+		return fmt.Errorf("dns query failed: %s", strings.ToUpper(r.Rcode))
+	}
+	if o == nil {
+		o = &ServiceDNSOptions{}
+	}
+	return o.Verify(r.Answers)
+}
+
+// Verify 校验应答记录是否包含全部期望值，未通过时返回的 error 即为失败原因
+func (o *ServiceDNSOptions) Verify(answers []string) error {
+	if len(answers) == 0 {
+		return errors.New("no records found")
+	}
+	got := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		got[o.normalize(a)] = true
+	}
+	for _, e := range o.Expected {
+		if !got[o.normalize(e)] {
+			return fmt.Errorf("expected %s not in answers: %s", e, strings.Join(answers, ","))
+		}
+	}
+	return nil
+}
+
+// normalize 统一域名的大小写与末尾的点，TXT 记录区分大小写
+func (o *ServiceDNSOptions) normalize(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.EqualFold(o.RecordType, "TXT") {
+		return v
+	}
+	return strings.TrimSuffix(strings.ToLower(v), ".")
+}
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestServiceDNSOptionsEvaluate(t *testing.T) {
+	cases := []struct {
+		name    string
+		options *ServiceDNSOptions
+		result  TaskDNSResult
+		passed  bool
+	}{
+		{"answers", nil, TaskDNSResult{Rcode: "NOERROR", Answers: []string{"1.1.1.1"}}, true},
+		{"nxdomain", nil, TaskDNSResult{Rcode: "NXDOMAIN"}, false},
+		{"servfail with answers", nil, TaskDNSResult{Rcode: "SERVFAIL", Answers: []string{"1.1.1.1"}}, false},
+		{"no answers", nil, TaskDNSResult{Rcode: "NOERROR"}, false},
+		{"expected", &ServiceDNSOptions{Expected: []string{"1.1.1.1"}}, TaskDNSResult{Rcode: "NOERROR", Answers: []string{"1.0.0.1", "1.1.1.1"}}, true},
+		{"expected mismatch", &ServiceDNSOptions{Expected: []string{"1.1.1.1"}}, TaskDNSResult{Rcode: "NOERROR", Answers: []string{"1.0.0.1"}}, false},
+		{"cname case and dot", &ServiceDNSOptions{RecordType: "CNAME", Expected: []string{"Example.com"}}, TaskDNSResult{Answers: []string{"example.com."}}, true},
+		{"txt case sensitive", &ServiceDNSOptions{RecordType: "TXT", Expected: []string{"V=spf1"}}, TaskDNSResult{Answers: []string{"v=spf1"}}, false},
+	}
+	for _, c := range cases {
+		err := c.options.Evaluate(&c.result)
+		if c.passed && err != nil {
+			t.Fatalf("%s: expected passed, but got %v", c.name, err)
+		}
+		if !c.passed && err == nil {
+			t.Fatalf("%s: expected failure, but got passed", c.name)
+		}
+	}
+}
@@ -13,5 +13,7 @@ type ServiceHistory struct {
 	AvgDelay  float32   `gorm:"index:idx_server_id_created_at_service_id_avg_delay" json:"avg_delay,omitempty"` // 平均延迟，毫秒
 	Up        uint64    `json:"up,omitempty"`                                                                   // 检查状态良好计数
 	Down      uint64    `json:"down,omitempty"`                                                                 // 检查状态异常计数
+	Loss      float32   `json:"loss,omitempty"`                                                                 // 丢包率（百分比），仅 ping 监控
+	Jitter    float32   `json:"jitter,omitempty"`                                                               // 延迟抖动（标准差，毫秒），仅 ping 监控
 	Data      string    `json:"data,omitempty"`
 }
@@ -0,0 +1,222 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	JSONPathOperatorEqual       = "eq"
+	JSONPathOperatorNotEqual    = "ne"
+	JSONPathOperatorContains    = "contains"
+	JSONPathOperatorExists      = "exists"
+	JSONPathOperatorNotExists   = "not_exists"
+	JSONPathOperatorGreaterThan = "gt"
+	JSONPathOperatorLessThan    = "lt"
+)
+
+// ServiceHTTPOptions HTTP 服务监控的请求参数与断言配置
+type ServiceHTTPOptions struct {
+	Method         string            `json:"method,omitempty"`          // 请求方式，默认 GET
+	Headers        map[string]string `json:"headers,omitempty"`         // 请求头
+	Body           string            `json:"body,omitempty"`            // 请求体
+	ExpectedStatus []string          `json:"expected_status,omitempty"` // 期望的状态码，支持 200、2xx、200-299 三种写法，为空时仅接受 2xx 与 3xx
+
+	Keyword          string `json:"keyword,omitempty"`           // 响应体关键字
+	KeywordRegex     bool   `json:"keyword_regex,omitempty"`     // 关键字按正则表达式匹配
+	KeywordForbidden bool   `json:"keyword_forbidden,omitempty"` // 响应体中出现关键字时视为失败
+
+	JSONPath []JSONPathAssertion `json:"json_path,omitempty"` // JSON 响应断言，路径语法同 gjson
+}
+
+type JSONPathAssertion struct {
+	Path     string `json:"path"`
+	Operator string `json:"operator"` // eq、ne、contains、exists、not_exists、gt、lt
+	Value    string `json:"value,omitempty"`
+}
+
+// TaskHTTPMinAgentVersion 支持 TaskHTTP 的最低 Agent 版本，更旧的 Agent 会把 JSON 任务数据当作 URL 请求，
+// 因此配置了请求参数或断言的 HTTP 监控不会下发给这些 Agent
+const TaskHTTPMinAgentVersion = "1.10.0"
+
+// TaskHTTP 配置了请求参数或断言的 HTTP 监控任务，序列化后作为 pb.Task.Data 下发，
+// 断言由 Agent 执行，规则与 ServiceHTTPOptions.Verify 一致
+type TaskHTTP struct {
+	URL string `json:"url"`
+	ServiceHTTPOptions
+}
+
+// IsEmpty 未配置任何请求参数与断言时返回 true，此时按旧格式只下发 URL
+func (o *ServiceHTTPOptions) IsEmpty() bool {
+	return o == nil || (o.Method == "" && len(o.Headers) == 0 && o.Body == "" &&
+		len(o.ExpectedStatus) == 0 && o.Keyword == "" && len(o.JSONPath) == 0)
+}
+
+// Validate 检查配置是否合法
+func (o *ServiceHTTPOptions) Validate() error {
+	switch strings.ToUpper(o.Method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("unsupported http method: %s", o.Method)
+	}
+	for _, s := range o.ExpectedStatus {
+		if _, _, err := parseStatusPattern(s); err != nil {
+			return err
+		}
+	}
+	if o.KeywordRegex {
+		if _, err := regexp.Compile(o.Keyword); err != nil {
+			return err
+		}
+	}
+	for _, a := range o.JSONPath {
+		if a.Path == "" {
+			return errors.New("json path is empty")
+		}
+		switch a.Operator {
+		case JSONPathOperatorEqual, JSONPathOperatorNotEqual, JSONPathOperatorContains,
+			JSONPathOperatorExists, JSONPathOperatorNotExists:
+		case JSONPathOperatorGreaterThan, JSONPathOperatorLessThan:
+			if _, err := strconv.ParseFloat(a.Value, 64); err != nil {
+				return fmt.Errorf("json path %s: value %q is not a number", a.Path, a.Value)
+			}
+		default:
+			return fmt.Errorf("json path %s: unsupported operator %q", a.Path, a.Operator)
+		}
+	}
+	return nil
+}
+
+// Verify 校验 HTTP 响应是否满足断言，未通过时返回的 error 即为失败原因，Agent 执行断言时需与此保持一致
+func (o *ServiceHTTPOptions) Verify(statusCode int, body []byte) error {
+	if err := o.verifyStatus(statusCode); err != nil {
+		return err
+	}
+	if err := o.verifyKeyword(body); err != nil {
+		return err
+	}
+	if len(o.JSONPath) > 0 && !gjson.ValidBytes(body) {
+		return errors.New("response body is not valid json")
+	}
+	for _, a := range o.JSONPath {
+		if err := a.verify(body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *ServiceHTTPOptions) verifyStatus(statusCode int) error {
+	if len(o.ExpectedStatus) == 0 {
+		if statusCode >= 200 && statusCode < 400 {
+			return nil
+		}
+		return fmt.Errorf("unexpected status code %d", statusCode)
+	}
+	for _, s := range o.ExpectedStatus {
+		min, max, err := parseStatusPattern(s)
+		if err != nil {
+			return err
+		}
+		if statusCode >= min && statusCode <= max {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected status code %d, expected %s", statusCode, strings.Join(o.ExpectedStatus, ","))
+}
+
+func (o *ServiceHTTPOptions) verifyKeyword(body []byte) error {
+	if o.Keyword == "" {
+		return nil
+	}
+	var found bool
+	if o.KeywordRegex {
+		re, err := regexp.Compile(o.Keyword)
+		if err != nil {
+			return err
+		}
+		found = re.Match(body)
+	} else {
+		found = strings.Contains(string(body), o.Keyword)
+	}
+	if o.KeywordForbidden && found {
+		return fmt.Errorf("forbidden keyword %q found in response", o.Keyword)
+	}
+	if !o.KeywordForbidden && !found {
+		return fmt.Errorf("keyword %q not found in response", o.Keyword)
+	}
+	return nil
+}
+
+func (a *JSONPathAssertion) verify(body []byte) error {
+	result := gjson.GetBytes(body, a.Path)
+	switch a.Operator {
+	case JSONPathOperatorExists:
+		if !result.Exists() {
+			return fmt.Errorf("json path %s does not exist", a.Path)
+		}
+		return nil
+	case JSONPathOperatorNotExists:
+		if result.Exists() {
+			return fmt.Errorf("json path %s exists", a.Path)
+		}
+		return nil
+	}
+	if !result.Exists() {
+		return fmt.Errorf("json path %s does not exist", a.Path)
+	}
+	var passed bool
+	switch a.Operator {
+	case JSONPathOperatorEqual:
+		passed = result.String() == a.Value
+	case JSONPathOperatorNotEqual:
+		passed = result.String() != a.Value
+	case JSONPathOperatorContains:
+		passed = strings.Contains(result.String(), a.Value)
+	case JSONPathOperatorGreaterThan, JSONPathOperatorLessThan:
+		v, err := strconv.ParseFloat(a.Value, 64)
+		if err != nil {
+			return err
+		}
+		if a.Operator == JSONPathOperatorGreaterThan {
+			passed = result.Float() > v
+		} else {
+			passed = result.Float() < v
+		}
+	default:
+		return fmt.Errorf("json path %s: unsupported operator %q", a.Path, a.Operator)
+	}
+	if !passed {
+		return fmt.Errorf("json path %s: %q %s %q failed", a.Path, result.String(), a.Operator, a.Value)
+	}
+	return nil
+}
+
+// parseStatusPattern 将 200、2xx、200-299 解析为闭区间
+func parseStatusPattern(s string) (int, int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5' {
+		base := int(s[0]-'0') * 100
+		return base, base + 99, nil
+	}
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		min, err1 := strconv.Atoi(strings.TrimSpace(from))
+		max, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || min > max || min < 100 || max > 599 {
+			return 0, 0, fmt.Errorf("invalid status code range: %s", s)
+		}
+		return min, max, nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, fmt.Errorf("invalid status code: %s", s)
+	}
+	return code, code, nil
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+type httpVerifyCase struct {
+	options    ServiceHTTPOptions
+	statusCode int
+	body       string
+	passed     bool
+}
+
+func TestServiceHTTPOptionsVerify(t *testing.T) {
+	cases := []httpVerifyCase{
+		{options: ServiceHTTPOptions{}, statusCode: 200, passed: true},
+		{options: ServiceHTTPOptions{}, statusCode: 302, passed: true},
+		{options: ServiceHTTPOptions{}, statusCode: 500, passed: false},
+		{options: ServiceHTTPOptions{ExpectedStatus: []string{"500"}}, statusCode: 500, passed: true},
+		{options: ServiceHTTPOptions{ExpectedStatus: []string{"2xx"}}, statusCode: 204, passed: true},
+		{options: ServiceHTTPOptions{ExpectedStatus: []string{"2xx"}}, statusCode: 301, passed: false},
+		{options: ServiceHTTPOptions{ExpectedStatus: []string{"401", "200-204"}}, statusCode: 203, passed: true},
+		{options: ServiceHTTPOptions{Keyword: "ok"}, statusCode: 200, body: "status: ok", passed: true},
+		{options: ServiceHTTPOptions{Keyword: "ok"}, statusCode: 200, body: "status: fail", passed: false},
+		{options: ServiceHTTPOptions{Keyword: "error", KeywordForbidden: true}, statusCode: 200, body: "fatal error", passed: false},
+		{options: ServiceHTTPOptions{Keyword: `^v\d+\.\d+$`, KeywordRegex: true}, statusCode: 200, body: "v1.12", passed: true},
+		{options: ServiceHTTPOptions{Keyword: `^v\d+\.\d+$`, KeywordRegex: true}, statusCode: 200, body: "v1.x", passed: false},
+		{
+			options: ServiceHTTPOptions{JSONPath: []JSONPathAssertion{
+				{Path: "status", Operator: JSONPathOperatorEqual, Value: "ok"},
+				{Path: "data.count", Operator: JSONPathOperatorGreaterThan, Value: "3"},
+				{Path: "data.error", Operator: JSONPathOperatorNotExists},
+			}},
+			statusCode: 200,
+			body:       `{"status":"ok","data":{"count":5}}`,
+			passed:     true,
+		},
+		{
+			options:    ServiceHTTPOptions{JSONPath: []JSONPathAssertion{{Path: "data.count", Operator: JSONPathOperatorLessThan, Value: "3"}}},
+			statusCode: 200,
+			body:       `{"data":{"count":5}}`,
+			passed:     false,
+		},
+		{
+			options:    ServiceHTTPOptions{JSONPath: []JSONPathAssertion{{Path: "status", Operator: JSONPathOperatorExists}}},
+			statusCode: 200,
+			body:       `<html></html>`,
+			passed:     false,
+		},
+	}
+
+	for i, c := range cases {
+		err := c.options.Verify(c.statusCode, []byte(c.body))
+		if c.passed && err != nil {
+			t.Fatalf("case %d: expected passed, but got %v", i, err)
+		}
+		if !c.passed && err == nil {
+			t.Fatalf("case %d: expected failure, but passed", i)
+		}
+	}
+}
+
+func TestServiceHTTPOptionsValidate(t *testing.T) {
+	invalid := []ServiceHTTPOptions{
+		{Method: "CONNECT"},
+		{ExpectedStatus: []string{"600"}},
+		{ExpectedStatus: []string{"299-200"}},
+		{Keyword: "(", KeywordRegex: true},
+		{JSONPath: []JSONPathAssertion{{Path: "a", Operator: "regex"}}},
+		{JSONPath: []JSONPathAssertion{{Path: "a", Operator: JSONPathOperatorGreaterThan, Value: "x"}}},
+	}
+	for i, o := range invalid {
+		if err := o.Validate(); err == nil {
+			t.Fatalf("case %d: expected error, but got nil", i)
+		}
+	}
+
+	valid := ServiceHTTPOptions{Method: "post", ExpectedStatus: []string{"2xx", "404"}, Keyword: "ok"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+}
+
+func TestServiceTaskData(t *testing.T) {
+	s := Service{Type: TaskTypeHTTPGet, Target: "https://example.com"}
+	if data := s.PB().GetData(); data != s.Target {
+		t.Fatalf("Expected %s, but got %s", s.Target, data)
+	}
+
+	s.HTTPOptions = &ServiceHTTPOptions{Method: "POST", Keyword: "ok"}
+	var task TaskHTTP
+	if err := utils.Json.Unmarshal([]byte(s.PB().GetData()), &task); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if task.URL != s.Target || task.Method != "POST" || task.Keyword != "ok" {
+		t.Fatalf("Unexpected task data: %+v", task)
+	}
+}
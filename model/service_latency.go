@@ -0,0 +1,101 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// LatencySample 单次监控结果的延迟样本
+type LatencySample struct {
+	Delay      float32
+	Successful bool
+	CreatedAt  time.Time
+}
+
+// ServiceLatencyStats 服务监控在单个上报服务器上的延迟统计
+type ServiceLatencyStats struct {
+	ServiceID   uint64    `json:"monitor_id"`
+	ServerID    uint64    `json:"server_id"`
+	ServiceName string    `json:"monitor_name"`
+	ServerName  string    `json:"server_name"`
+	Latest      float32   `json:"latest"`    // 最近一次成功的延迟
+	LatestAt    time.Time `json:"latest_at"` // 最近一次上报的时间
+	P50         float32   `json:"p50"`
+	P95         float32   `json:"p95"`
+	P99         float32   `json:"p99"`
+	Loss        float32   `json:"loss"`    // 失败率（百分比）
+	Jitter      float32   `json:"jitter"`  // 延迟抖动（标准差）
+	Samples     int       `json:"samples"` // 参与统计的样本数
+}
+
+// CalculateLatencyStats 计算样本的延迟分位数与失败率，samples 按上报时间升序排列
+func CalculateLatencyStats(samples []LatencySample) ServiceLatencyStats {
+	var stats ServiceLatencyStats
+	stats.Samples = len(samples)
+	if len(samples) == 0 {
+		return stats
+	}
+	stats.LatestAt = samples[len(samples)-1].CreatedAt
+
+	delays := make([]float32, 0, len(samples))
+	for _, s := range samples {
+		if s.Successful {
+			delays = append(delays, s.Delay)
+			stats.Latest = s.Delay
+		}
+	}
+	stats.Loss = float32(len(samples)-len(delays)) * 100 / float32(len(samples))
+	_, stats.Jitter = meanAndStddev(delays)
+
+	slices.Sort(delays)
+	stats.P50 = percentile(delays, 50)
+	stats.P95 = percentile(delays, 95)
+	stats.P99 = percentile(delays, 99)
+	return stats
+}
+
+// CalculatePingWindow 计算一个平均周期内 ping 结果的平均延迟、丢包率与抖动，失败结果只计入丢包率
+func CalculatePingWindow(samples []LatencySample) (avgDelay, loss, jitter float32) {
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+	delays := make([]float32, 0, len(samples))
+	for _, s := range samples {
+		if s.Successful {
+			delays = append(delays, s.Delay)
+		}
+	}
+	loss = float32(len(samples)-len(delays)) * 100 / float32(len(samples))
+	avgDelay, jitter = meanAndStddev(delays)
+	return avgDelay, loss, jitter
+}
+
+// meanAndStddev 计算平均值与总体标准差
+func meanAndStddev(values []float32) (float32, float32) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (float64(v) - mean) * (float64(v) - mean)
+	}
+	return float32(mean), float32(math.Sqrt(variance / float64(len(values))))
+}
+
+// percentile 按最近秩法计算已排序数据的分位数
+func percentile(sorted []float32, p float64) float32 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateLatencyStats(t *testing.T) {
+	now := time.Now()
+	var samples []LatencySample
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, LatencySample{Delay: float32(i), Successful: i%10 != 0, CreatedAt: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	stats := CalculateLatencyStats(samples)
+	if stats.Samples != 100 || stats.Loss != 10 {
+		t.Fatalf("Expected 100 samples with 10%% loss, but got %d %f", stats.Samples, stats.Loss)
+	}
+	if stats.Latest != 99 || !stats.LatestAt.Equal(samples[99].CreatedAt) {
+		t.Fatalf("Expected latest 99, but got %f", stats.Latest)
+	}
+	if stats.P50 != 49 || stats.P95 != 95 || stats.P99 != 99 {
+		t.Fatalf("Expected p50/p95/p99 49/95/99, but got %f/%f/%f", stats.P50, stats.P95, stats.P99)
+	}
+
+	if stats := CalculateLatencyStats(nil); stats.Samples != 0 || stats.P99 != 0 {
+		t.Fatalf("Unexpected stats for empty samples: %+v", stats)
+	}
+}
+
+func TestCalculatePingWindow(t *testing.T) {
+	samples := []LatencySample{
+		{Delay: 10, Successful: true},
+		{Delay: 20, Successful: true},
+		{Successful: false},
+		{Delay: 30, Successful: true},
+	}
+	avg, loss, jitter := CalculatePingWindow(samples)
+	if avg != 20 || loss != 25 || jitter < 8.16 || jitter > 8.17 {
+		t.Fatalf("Expected 20/25/8.16, but got %f/%f/%f", avg, loss, jitter)
+	}
+
+	if avg, loss, jitter := CalculatePingWindow([]LatencySample{{Successful: false}}); avg != 0 || loss != 100 || jitter != 0 {
+		t.Fatalf("Expected 0/100/0, but got %f/%f/%f", avg, loss, jitter)
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// ServiceLocationStatus 单个监测点（上报服务器）最近一次的监控结果
+type ServiceLocationStatus struct {
+	ServerName string    `json:"server_name,omitempty"`
+	Successful bool      `json:"successful"`
+	Delay      float32   `json:"delay"`
+	Data       string    `json:"data,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QuorumEnabled 是否按多监测点共识判定服务状态
+func (m *Service) QuorumEnabled() bool {
+	return m.QuorumFailures > 0 && m.IsAgentTask()
+}
+
+// LocationResultTTL 监测点结果的有效期，超过两个监控周期未上报的监测点不参与判定
+func (m *Service) LocationResultTTL() time.Duration {
+	duration := m.Duration
+	if duration == 0 {
+		duration = 30
+	}
+	return time.Duration(duration*2) * time.Second
+}
+
+// EvaluateQuorum 统计有效期内的监测点结果，返回失败的监测点 ID（升序）与参与判定的监测点数量
+func (m *Service) EvaluateQuorum(locations map[uint64]*ServiceLocationStatus, now time.Time) ([]uint64, int) {
+	var failed []uint64
+	var total int
+	for id, l := range locations {
+		if l == nil || now.Sub(l.UpdatedAt) > m.LocationResultTTL() {
+			continue
+		}
+		total++
+		if !l.Successful {
+			failed = append(failed, id)
+		}
+	}
+	slices.Sort(failed)
+	return failed, total
+}
+
+// QuorumDown 失败的监测点数量达到阈值时判定为故障
+func (m *Service) QuorumDown(failed int) bool {
+	return m.QuorumEnabled() && uint64(failed) >= m.QuorumFailures
+}
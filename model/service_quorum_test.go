@@ -0,0 +1,41 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestServiceEvaluateQuorum(t *testing.T) {
+	now := time.Now()
+	s := Service{Type: TaskTypeHTTPGet, Duration: 30, QuorumProbes: 4, QuorumFailures: 2}
+	locations := map[uint64]*ServiceLocationStatus{
+		1: {Successful: true, UpdatedAt: now},
+		2: {Successful: false, UpdatedAt: now.Add(-10 * time.Second)},
+		3: {Successful: false, UpdatedAt: now.Add(-2 * time.Minute)}, // 已过期
+		4: {Successful: true, UpdatedAt: now},
+	}
+
+	failed, total := s.EvaluateQuorum(locations, now)
+	if !slices.Equal(failed, []uint64{2}) || total != 3 {
+		t.Fatalf("Expected [2] 3, but got %v %d", failed, total)
+	}
+	if s.QuorumDown(len(failed)) {
+		t.Fatalf("Expected up with %d failed locations", len(failed))
+	}
+
+	locations[4].Successful = false
+	failed, _ = s.EvaluateQuorum(locations, now)
+	if !s.QuorumDown(len(failed)) {
+		t.Fatalf("Expected down with %d failed locations", len(failed))
+	}
+
+	s.QuorumFailures = 0
+	if s.QuorumEnabled() || s.QuorumDown(len(failed)) {
+		t.Fatal("Expected quorum disabled")
+	}
+	s = Service{Type: TaskTypeHeartbeat, QuorumFailures: 1}
+	if s.QuorumEnabled() {
+		t.Fatal("Expected quorum disabled for heartbeat service")
+	}
+}
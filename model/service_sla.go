@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSLARetentionDays 服务监控每日统计默认保留天数
+const DefaultSLARetentionDays = 730
+
+// ServiceIncident 服务监控的一次故障，从状态变为故障开始，到状态恢复结束
+type ServiceIncident struct {
+	Common
+	ServiceID uint64     `json:"service_id" gorm:"index"`
+	StartedAt time.Time  `json:"started_at" gorm:"index"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"` // 为空时表示故障仍在持续
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// ServiceDailyStats 服务监控的每日统计，用于 SLA 报表
+type ServiceDailyStats struct {
+	ID                 uint64    `gorm:"primaryKey" json:"-"`
+	ServiceID          uint64    `gorm:"uniqueIndex:idx_service_daily_stats_service_id_date" json:"service_id"`
+	Date               time.Time `gorm:"uniqueIndex:idx_service_daily_stats_service_id_date" json:"date"` // 当日零点
+	Up                 uint64    `json:"up"`                                                              // 检查状态良好计数
+	Down               uint64    `json:"down"`                                                            // 检查状态异常计数
+	AvgDelay           float32   `json:"avg_delay"`
+	Downtime           uint64    `json:"downtime"`            // 当日处于故障状态的时长（秒）
+	Incidents          uint64    `json:"incidents"`           // 当日开始的故障次数
+	RecoveredIncidents uint64    `json:"recovered_incidents"` // 当日恢复的故障次数
+	RecoverySeconds    uint64    `json:"recovery_seconds"`    // 当日恢复的故障的总持续时长（秒）
+}
+
+// AddIncident 将故障计入 [dayStart, dayEnd) 的统计，持续中的故障以 now 作为结束时间计算故障时长
+func (s *ServiceDailyStats) AddIncident(incident *ServiceIncident, dayStart, dayEnd, now time.Time) {
+	end := now
+	if incident.EndedAt != nil {
+		end = *incident.EndedAt
+	}
+	if !incident.StartedAt.Before(dayEnd) || !end.After(dayStart) {
+		return
+	}
+
+	from, to := incident.StartedAt, end
+	if from.Before(dayStart) {
+		from = dayStart
+	}
+	if to.After(dayEnd) {
+		to = dayEnd
+	}
+	if to.After(from) {
+		s.Downtime += uint64(to.Sub(from).Seconds())
+	}
+
+	if !incident.StartedAt.Before(dayStart) {
+		s.Incidents++
+	}
+	if incident.EndedAt != nil && !incident.EndedAt.Before(dayStart) && incident.EndedAt.Before(dayEnd) {
+		s.RecoveredIncidents++
+		s.RecoverySeconds += uint64(incident.EndedAt.Sub(incident.StartedAt).Seconds())
+	}
+}
+
+// SLAReport 服务监控在一个统计周期内的 SLA 报表
+type SLAReport struct {
+	ServiceID     uint64              `json:"service_id"`
+	ServiceName   string              `json:"service_name"`
+	Period        string              `json:"period"`
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	UptimePercent float64             `json:"uptime_percent"` // 检查状态良好的比例，没有数据时为 100
+	Downtime      uint64              `json:"downtime"`       // 故障总时长（秒）
+	Incidents     uint64              `json:"incidents"`      // 故障次数
+	MTTR          uint64              `json:"mttr"`           // 平均恢复时长（秒）
+	Days          []ServiceDailyStats `json:"days,omitempty"`
+}
+
+// NewSLAReport 汇总每日统计生成 SLA 报表
+func NewSLAReport(service *Service, period string, from, to time.Time, days []ServiceDailyStats) SLAReport {
+	report := SLAReport{
+		ServiceID:   service.ID,
+		ServiceName: service.Name,
+		Period:      period,
+		From:        from,
+		To:          to,
+		Days:        days,
+	}
+
+	var up, down, recovered, recoverySeconds uint64
+	for _, d := range days {
+		up += d.Up
+		down += d.Down
+		report.Downtime += d.Downtime
+		report.Incidents += d.Incidents
+		recovered += d.RecoveredIncidents
+		recoverySeconds += d.RecoverySeconds
+	}
+	report.UptimePercent = 100
+	if up+down > 0 {
+		report.UptimePercent = float64(up) * 100 / float64(up+down)
+	}
+	if recovered > 0 {
+		report.MTTR = recoverySeconds / recovered
+	}
+	return report
+}
+
+// ParseSLAPeriod 解析统计周期，支持月份（2006-01）与季度（2006-Q1），返回 [from, to)
+func ParseSLAPeriod(period string, loc *time.Location) (time.Time, time.Time, error) {
+	period = strings.ToUpper(strings.TrimSpace(period))
+	if year, quarter, ok := strings.Cut(period, "-Q"); ok {
+		y, err1 := strconv.Atoi(year)
+		q, err2 := strconv.Atoi(quarter)
+		if err1 != nil || err2 != nil || q < 1 || q > 4 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: %s", period)
+		}
+		from := time.Date(y, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 3, 0), nil
+	}
+	month, err := time.ParseInLocation("2006-01", period, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period: %s", period)
+	}
+	return month, month.AddDate(0, 1, 0), nil
+}
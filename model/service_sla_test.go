@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSLAPeriod(t *testing.T) {
+	cases := []struct {
+		period   string
+		from, to string
+	}{
+		{"2026-09", "2026-09-01", "2026-10-01"},
+		{"2026-12", "2026-12-01", "2027-01-01"},
+		{"2026-q3", "2026-07-01", "2026-10-01"},
+		{"2026-Q4", "2026-10-01", "2027-01-01"},
+	}
+	for _, c := range cases {
+		from, to, err := ParseSLAPeriod(c.period, time.UTC)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if from.Format(time.DateOnly) != c.from || to.Format(time.DateOnly) != c.to {
+			t.Fatalf("%s: expected %s ~ %s, but got %s ~ %s", c.period, c.from, c.to, from, to)
+		}
+	}
+
+	for _, p := range []string{"2026", "2026-13", "2026-Q5", "Q1-2026"} {
+		if _, _, err := ParseSLAPeriod(p, time.UTC); err == nil {
+			t.Fatalf("%s: expected error, but got nil", p)
+		}
+	}
+}
+
+func TestServiceDailyStatsAddIncident(t *testing.T) {
+	dayStart := time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	at := func(hour int) *time.Time {
+		t := dayStart.Add(time.Duration(hour) * time.Hour)
+		return &t
+	}
+
+	var s ServiceDailyStats
+	// 前一天开始，当天 1 点恢复
+	s.AddIncident(&ServiceIncident{StartedAt: *at(-2), EndedAt: at(1)}, dayStart, dayEnd, *at(30))
+	// 当天 10 点开始，11 点恢复
+	s.AddIncident(&ServiceIncident{StartedAt: *at(10), EndedAt: at(11)}, dayStart, dayEnd, *at(30))
+	// 当天 23 点开始，仍在持续
+	s.AddIncident(&ServiceIncident{StartedAt: *at(23)}, dayStart, dayEnd, *at(30))
+	// 与当天无关
+	s.AddIncident(&ServiceIncident{StartedAt: *at(25), EndedAt: at(26)}, dayStart, dayEnd, *at(30))
+
+	if s.Downtime != 3*3600 || s.Incidents != 2 || s.RecoveredIncidents != 2 || s.RecoverySeconds != 4*3600 {
+		t.Fatalf("Unexpected stats: %+v", s)
+	}
+
+	report := NewSLAReport(&Service{Name: "test"}, "2026-09", dayStart, dayEnd, []ServiceDailyStats{
+		s, {Up: 90, Down: 10},
+	})
+	if report.UptimePercent != 90 || report.Downtime != 3*3600 || report.Incidents != 2 || report.MTTR != 2*3600 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+const (
+	StartTLSNone = ""
+	StartTLSSMTP = "smtp"
+	StartTLSIMAP = "imap"
+	StartTLSPOP3 = "pop3"
+)
+
+var (
+	// DefaultTLSExpiryThresholds TLS 监控默认的证书过期提醒天数
+	DefaultTLSExpiryThresholds = []uint64{30, 14, 7}
+	// legacyHTTPExpiryThresholds HTTP 监控附带证书检查时沿用的提醒天数
+	legacyHTTPExpiryThresholds = []uint64{7}
+)
+
+// ServiceTLSOptions TLS 证书监控参数，Service.Target 为 host:port
+type ServiceTLSOptions struct {
+	ServerName       string   `json:"server_name,omitempty"`       // SNI 与主机名校验使用的域名，为空时使用 Target 中的主机
+	StartTLS         string   `json:"starttls,omitempty"`          // 明文协议升级方式：smtp、imap、pop3，为空时直接进行 TLS 握手
+	SkipChainVerify  bool     `json:"skip_chain_verify,omitempty"` // 跳过证书链与主机名校验，仅检查过期时间
+	ExpiryThresholds []uint64 `json:"expiry_thresholds,omitempty"` // 证书过期提醒天数，HTTP 监控同样适用
+}
+
+// TaskTLS TLS 证书监控任务，序列化后作为 pb.Task.Data 下发。Agent 需要：
+//   - 连接 Target，按 StartTLS 升级后以 ServerName（为空时为 Target 中的主机）作为 SNI 完成握手，失败时上报失败原因
+//   - 按系统根证书校验证书链，结果填入 TLSCertInfo.ChainValid
+//   - 校验叶子证书是否匹配 ServerName，结果填入 TLSCertInfo.HostnameMatch
+//   - 将叶子证书信息与校验失败原因序列化为 TLSCertInfo，作为 pb.TaskResult.Data 上报；SkipChainVerify 时同样需要上报
+//
+// 证书链、主机名与有效期是否合格由面板按 ServiceTLSOptions.Evaluate 判定
+type TaskTLS struct {
+	Target string `json:"target"`
+	ServiceTLSOptions
+}
+
+// TLSCertInfo Agent 完成握手后上报的证书信息
+type TLSCertInfo struct {
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	SANs          []string  `json:"sans,omitempty"`
+	NotBefore     time.Time `json:"not_before,omitempty"`
+	NotAfter      time.Time `json:"not_after,omitempty"`
+	ChainValid    bool      `json:"chain_valid"`
+	HostnameMatch bool      `json:"hostname_match"`
+	Error         string    `json:"error,omitempty"` // 证书链或主机名校验失败的原因
+}
+
+// Validate 检查配置是否合法
+func (o *ServiceTLSOptions) Validate() error {
+	switch o.StartTLS {
+	case StartTLSNone, StartTLSSMTP, StartTLSIMAP, StartTLSPOP3:
+	default:
+		return fmt.Errorf("unsupported starttls protocol: %s", o.StartTLS)
+	}
+	for _, t := range o.ExpiryThresholds {
+		if t == 0 {
+			return errors.New("expiry threshold must be greater than 0")
+		}
+	}
+	return nil
+}
+
+// Evaluate 判定 Agent 上报的证书信息，证书链或主机名校验失败（未跳过校验时）、证书未生效或已过期时返回失败原因
+func (o *ServiceTLSOptions) Evaluate(info *TLSCertInfo, now time.Time) error {
+	if o == nil || !o.SkipChainVerify {
+		if !info.ChainValid || !info.HostnameMatch {
+			if info.Error != "" {
+				return errors.New(info.Error)
+			}
+			if !info.ChainValid {
+				return errors.New("certificate chain verification failed")
+			}
+			return errors.New("certificate does not match the server name")
+		}
+	}
+	if !info.NotBefore.IsZero() && now.Before(info.NotBefore) {
+		return fmt.Errorf("certificate is not valid before %s", info.NotBefore.Format(time.RFC3339))
+	}
+	if !info.NotAfter.IsZero() && now.After(info.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", info.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ValidateTLSTarget 检查 TLS 监控目标是否为 host:port
+func ValidateTLSTarget(target string) error {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return err
+	}
+	if host == "" || port == "" {
+		return fmt.Errorf("invalid target: %s", target)
+	}
+	return nil
+}
+
+// TLSExpiryThresholds 返回该服务证书过期提醒天数，按从大到小排序
+func (m *Service) TLSExpiryThresholds() []uint64 {
+	var thresholds []uint64
+	if m.TLSOptions != nil && len(m.TLSOptions.ExpiryThresholds) > 0 {
+		thresholds = slices.Clone(m.TLSOptions.ExpiryThresholds)
+	} else if m.Type == TaskTypeTLS {
+		thresholds = slices.Clone(DefaultTLSExpiryThresholds)
+	} else {
+		thresholds = slices.Clone(legacyHTTPExpiryThresholds)
+	}
+	slices.Sort(thresholds)
+	slices.Reverse(thresholds)
+	return thresholds
+}
+
+// ReachedTLSExpiryThreshold 返回证书剩余天数已达到的最小提醒天数，未达到任何提醒天数时返回 false
+func (m *Service) ReachedTLSExpiryThreshold(expires time.Time) (uint64, bool) {
+	var reached uint64
+	var ok bool
+	for _, t := range m.TLSExpiryThresholds() {
+		if expires.Before(time.Now().AddDate(0, 0, int(t))) {
+			reached, ok = t, true
+		}
+	}
+	return reached, ok
+}
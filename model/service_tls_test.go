@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceReachedTLSExpiryThreshold(t *testing.T) {
+	cases := []struct {
+		service   Service
+		expiresIn time.Duration
+		threshold uint64
+		reached   bool
+	}{
+		{service: Service{Type: TaskTypeTLS}, expiresIn: 40 * 24 * time.Hour},
+		{service: Service{Type: TaskTypeTLS}, expiresIn: 20 * 24 * time.Hour, threshold: 30, reached: true},
+		{service: Service{Type: TaskTypeTLS}, expiresIn: 3 * 24 * time.Hour, threshold: 7, reached: true},
+		{service: Service{Type: TaskTypeHTTPGet}, expiresIn: 20 * 24 * time.Hour},
+		{service: Service{Type: TaskTypeHTTPGet}, expiresIn: 5 * 24 * time.Hour, threshold: 7, reached: true},
+		{
+			service:   Service{Type: TaskTypeHTTPGet, TLSOptions: &ServiceTLSOptions{ExpiryThresholds: []uint64{3, 60}}},
+			expiresIn: 20 * 24 * time.Hour,
+			threshold: 60,
+			reached:   true,
+		},
+	}
+
+	for i, c := range cases {
+		threshold, reached := c.service.ReachedTLSExpiryThreshold(time.Now().Add(c.expiresIn))
+		if threshold != c.threshold || reached != c.reached {
+			t.Fatalf("case %d: expected %d %v, but got %d %v", i, c.threshold, c.reached, threshold, reached)
+		}
+	}
+}
+
+func TestServiceTLSOptionsEvaluate(t *testing.T) {
+	now := time.Now()
+	valid := TLSCertInfo{ChainValid: true, HostnameMatch: true, NotBefore: now.AddDate(0, -1, 0), NotAfter: now.AddDate(0, 1, 0)}
+	withInfo := func(f func(*TLSCertInfo)) TLSCertInfo {
+		info := valid
+		f(&info)
+		return info
+	}
+	cases := []struct {
+		name    string
+		options *ServiceTLSOptions
+		info    TLSCertInfo
+		passed  bool
+	}{
+		{"valid", nil, valid, true},
+		{"chain invalid", nil, withInfo(func(i *TLSCertInfo) { i.ChainValid = false }), false},
+		{"hostname mismatch", &ServiceTLSOptions{}, withInfo(func(i *TLSCertInfo) { i.HostnameMatch = false }), false},
+		{"skip chain verify", &ServiceTLSOptions{SkipChainVerify: true}, withInfo(func(i *TLSCertInfo) { i.ChainValid, i.HostnameMatch = false, false }), true},
+		{"expired", &ServiceTLSOptions{SkipChainVerify: true}, withInfo(func(i *TLSCertInfo) { i.NotAfter = now.Add(-time.Hour) }), false},
+		{"not yet valid", nil, withInfo(func(i *TLSCertInfo) { i.NotBefore = now.Add(time.Hour) }), false},
+	}
+	for _, c := range cases {
+		err := c.options.Evaluate(&c.info, now)
+		if c.passed && err != nil {
+			t.Fatalf("%s: expected passed, but got %v", c.name, err)
+		}
+		if !c.passed && err == nil {
+			t.Fatalf("%s: expected failure, but got passed", c.name)
+		}
+	}
+}
@@ -13,9 +13,13 @@ type SettingForm struct {
 	RealIPHeader                string `json:"real_ip_header,omitempty" validate:"optional"` // 真实IP
 	UserTemplate                string `json:"user_template,omitempty" validate:"optional"`
 
+	Retention *RetentionPolicy `json:"retention,omitempty" validate:"optional"` // 为空时不修改数据保留策略
+
 	TLS                         bool `json:"tls,omitempty" validate:"optional"`
 	EnableIPChangeNotification  bool `json:"enable_ip_change_notification,omitempty" validate:"optional"`
 	EnablePlainIPInNotification bool `json:"enable_plain_ip_in_notification,omitempty" validate:"optional"`
+	DisableAgentAutoRegister    bool `json:"disable_agent_auto_register,omitempty" validate:"optional"`
+	AgentApprovalRequired       bool `json:"agent_approval_required,omitempty" validate:"optional"`
 }
 
 type FrontendTemplate struct {
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	StatusPageNoticeIncident    = "incident"    // 故障公告
+	StatusPageNoticeMaintenance = "maintenance" // 维护公告
+)
+
+// StatusPage 公开状态页，展示选定的服务监控与服务器
+type StatusPage struct {
+	Common
+	Slug        string `json:"slug" gorm:"unique"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Domain      string `json:"domain,omitempty" gorm:"index"` // 自定义域名，按请求的 Host 匹配
+	ServicesRaw string `gorm:"default:'[]'" json:"-"`
+	ServersRaw  string `gorm:"default:'[]'" json:"-"`
+
+	Services []uint64 `gorm:"-" json:"services"` // 展示的服务监控 ID，按顺序展示
+	Servers  []uint64 `gorm:"-" json:"servers"`  // 展示的服务器 ID，按顺序展示
+}
+
+func (p *StatusPage) BeforeSave(tx *gorm.DB) error {
+	if data, err := utils.Json.Marshal(p.Services); err != nil {
+		return err
+	} else {
+		p.ServicesRaw = string(data)
+	}
+	if data, err := utils.Json.Marshal(p.Servers); err != nil {
+		return err
+	} else {
+		p.ServersRaw = string(data)
+	}
+	return nil
+}
+
+func (p *StatusPage) AfterFind(tx *gorm.DB) error {
+	if err := utils.Json.Unmarshal([]byte(p.ServicesRaw), &p.Services); err != nil {
+		return err
+	}
+	return utils.Json.Unmarshal([]byte(p.ServersRaw), &p.Servers)
+}
+
+// StatusPageNotice 状态页上手动发布的故障或维护公告
+type StatusPageNotice struct {
+	Common
+	StatusPageID uint64     `json:"status_page_id" gorm:"index"`
+	Type         string     `json:"type"` // incident、maintenance
+	Title        string     `json:"title"`
+	Content      string     `json:"content,omitempty"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at,omitempty"` // 故障解决或维护结束的时间，为空时表示仍在进行
+}
+
+// IsActive 公告在指定时间是否处于进行中
+func (n *StatusPageNotice) IsActive(now time.Time) bool {
+	return !now.Before(n.StartsAt) && (n.EndsAt == nil || now.Before(*n.EndsAt))
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type StatusPageForm struct {
+	Slug        string   `json:"slug,omitempty" minLength:"1"`
+	Title       string   `json:"title,omitempty" minLength:"1"`
+	Description string   `json:"description,omitempty" validate:"optional"`
+	Domain      string   `json:"domain,omitempty" validate:"optional"`
+	Services    []uint64 `json:"services,omitempty" validate:"optional"`
+	Servers     []uint64 `json:"servers,omitempty" validate:"optional"`
+}
+
+type StatusPageNoticeForm struct {
+	Type     string     `json:"type,omitempty" enums:"incident,maintenance"`
+	Title    string     `json:"title,omitempty" minLength:"1"`
+	Content  string     `json:"content,omitempty" validate:"optional"`
+	StartsAt time.Time  `json:"starts_at,omitempty" validate:"optional"` // 为空时为当前时间
+	EndsAt   *time.Time `json:"ends_at,omitempty" validate:"optional"`
+}
+
+type StatusPageService struct {
+	ServiceID uint64 `json:"service_id"`
+	ServiceResponseItem
+}
+
+type StatusPageResponse struct {
+	Slug        string              `json:"slug"`
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Services    []StatusPageService `json:"services,omitempty"`
+	Servers     []StreamServer      `json:"servers,omitempty"`
+	Notices     []*StatusPageNotice `json:"notices,omitempty"` // 进行中、即将开始与最近 7 天结束的公告
+}
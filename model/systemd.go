@@ -0,0 +1,90 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const MaxWatchedUnits = 64 // 每台服务器监控的 systemd 单元数量上限
+
+const (
+	SystemdUnitActive   = "active"
+	SystemdUnitInactive = "inactive"
+	SystemdUnitFailed   = "failed"
+)
+
+var systemdUnitNameRegex = regexp.MustCompile(`^[A-Za-z0-9:_.@\\-]+$`)
+
+// TaskSystemdUnits 监控的 systemd 单元列表，序列化后作为 pb.Task.Data 下发
+// Agent 在单元状态变化时以相同的任务类型上报 JSON 格式的 []SystemdUnit
+type TaskSystemdUnits struct {
+	Units []string `json:"units"`
+}
+
+// SystemdUnit systemd 单元的状态
+type SystemdUnit struct {
+	Name        string `json:"name"`
+	ActiveState string `json:"active_state"` // active、inactive、failed、activating 等
+	SubState    string `json:"sub_state"`    // running、exited、dead 等
+}
+
+// Down 单元是否处于失败或停止状态
+func (u *SystemdUnit) Down() bool {
+	return u.ActiveState == SystemdUnitFailed || u.ActiveState == SystemdUnitInactive
+}
+
+// NormalizeSystemdUnit 去除空白，未指定单元类型时补全为 .service
+func NormalizeSystemdUnit(name string) string {
+	name = strings.TrimSpace(name)
+	if name != "" && !strings.Contains(name, ".") {
+		name += ".service"
+	}
+	return name
+}
+
+// NormalizeWatchedUnits 规范化并去重监控的单元列表
+func NormalizeWatchedUnits(units []string) ([]string, error) {
+	var result []string
+	for _, u := range units {
+		u = NormalizeSystemdUnit(u)
+		if u == "" || slices.Contains(result, u) {
+			continue
+		}
+		if !systemdUnitNameRegex.MatchString(u) {
+			return nil, fmt.Errorf("invalid systemd unit name: %s", u)
+		}
+		result = append(result, u)
+	}
+	if len(result) > MaxWatchedUnits {
+		return nil, errors.New("too many watched units")
+	}
+	return result, nil
+}
+
+// FilterSystemdUnits 只保留仍在监控列表中的单元状态
+func FilterSystemdUnits(units []SystemdUnit, watched []string) []SystemdUnit {
+	var result []SystemdUnit
+	for _, u := range units {
+		if slices.Contains(watched, u.Name) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// systemdUnitDown 指定单元处于失败或停止状态时返回 true，name 为空时检查全部单元，未上报状态的单元不触发
+func systemdUnitDown(units []SystemdUnit, name string) bool {
+	name = NormalizeSystemdUnit(name)
+	for i := range units {
+		if name != "" && units[i].Name != name {
+			continue
+		}
+		if units[i].Down() {
+			return true
+		}
+	}
+	return false
+}
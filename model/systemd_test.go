@@ -0,0 +1,52 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeWatchedUnits(t *testing.T) {
+	units, err := NormalizeWatchedUnits([]string{" nginx ", "nginx.service", "backup.timer", "getty@tty1.service", ""})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	expected := []string{"nginx.service", "backup.timer", "getty@tty1.service"}
+	if !slices.Equal(units, expected) {
+		t.Fatalf("Expected %v, but got %v", expected, units)
+	}
+
+	if _, err := NormalizeWatchedUnits([]string{"nginx; reboot"}); err == nil {
+		t.Fatalf("Expected error for invalid unit name")
+	}
+}
+
+func TestSystemdUnitRule(t *testing.T) {
+	server := &Server{
+		Host:  &Host{},
+		State: &HostState{},
+		SystemdUnits: []SystemdUnit{
+			{Name: "nginx.service", ActiveState: "active", SubState: "running"},
+			{Name: "postgresql.service", ActiveState: "failed", SubState: "failed"},
+		},
+	}
+	cases := []struct {
+		unit     string
+		expected bool
+	}{
+		{"nginx", true},
+		{"postgresql.service", false},
+		{"redis", true}, // 未上报状态的单元不触发
+		{"", false},
+	}
+	for _, c := range cases {
+		rule := Rule{Type: "systemd_unit", Unit: c.unit}
+		if got := rule.Snapshot(nil, server, nil); got != c.expected {
+			t.Fatalf("Expected %v for unit %q, but got %v", c.expected, c.unit, got)
+		}
+	}
+
+	filtered := FilterSystemdUnits(server.SystemdUnits, []string{"nginx.service"})
+	if len(filtered) != 1 || filtered[0].Name != "nginx.service" {
+		t.Fatalf("Expected only nginx.service, but got %v", filtered)
+	}
+}
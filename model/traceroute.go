@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	TracerouteTriggerManual  = "manual"  // 手动发起
+	TracerouteTriggerLatency = "latency" // ping 监控延迟超过阈值时自动发起
+)
+
+const (
+	DefaultTracerouteMaxHops = 30
+	DefaultTracerouteCount   = 10
+)
+
+// TaskTraceroute 路由追踪任务，序列化后作为 pb.Task.Data 下发，Agent 以 JSON 格式的 []TracerouteHop 作为结果上报
+type TaskTraceroute struct {
+	Target  string `json:"target"`
+	MaxHops int    `json:"max_hops,omitempty"` // 最大跳数
+	Count   int    `json:"count,omitempty"`    // 每一跳的探测次数
+}
+
+// TracerouteHop 路由追踪中的一跳
+type TracerouteHop struct {
+	Hop        int      `json:"hop"`
+	Addresses  []string `json:"addresses,omitempty"` // 该跳响应的地址，无响应时为空
+	Loss       float32  `json:"loss"`                // 丢包率（百分比）
+	AvgDelay   float32  `json:"avg_delay"`           // 平均延迟，毫秒
+	BestDelay  float32  `json:"best_delay"`
+	WorstDelay float32  `json:"worst_delay"`
+}
+
+type Traceroute struct {
+	Common
+	ServerID   uint64 `gorm:"index" json:"server_id"`
+	ServiceID  uint64 `json:"service_id,omitempty"` // 由服务监控自动发起时对应的服务监控 ID
+	Target     string `json:"target"`
+	Trigger    string `json:"trigger"`
+	Finished   bool   `json:"finished"`
+	Successful bool   `json:"successful"`
+	Error      string `json:"error,omitempty"`
+	HopsRaw    string `gorm:"default:'[]'" json:"-"`
+
+	Hops []TracerouteHop `gorm:"-" json:"hops"`
+}
+
+func (t *Traceroute) BeforeSave(tx *gorm.DB) error {
+	if data, err := utils.Json.Marshal(t.Hops); err != nil {
+		return err
+	} else {
+		t.HopsRaw = string(data)
+	}
+	return nil
+}
+
+func (t *Traceroute) AfterFind(tx *gorm.DB) error {
+	if err := utils.Json.Unmarshal([]byte(t.HopsRaw), &t.Hops); err != nil {
+		log.Println("NEZHA>> Traceroute.AfterFind:", err)
+	}
+	return nil
+}
+
+// Validate 检查路由追踪任务参数
+func (t *TaskTraceroute) Validate() error {
+	if strings.TrimSpace(t.Target) == "" {
+		return errors.New("target is empty")
+	}
+	if t.MaxHops < 0 || t.MaxHops > 64 {
+		return errors.New("max hops must be between 1 and 64")
+	}
+	if t.Count < 0 || t.Count > 100 {
+		return errors.New("count must be between 1 and 100")
+	}
+	return nil
+}
+
+// TracerouteTarget 返回服务监控对应的路由追踪目标，TCP ping 的目标去掉端口
+func (m *Service) TracerouteTarget() string {
+	if m.Type == TaskTypeTCPPing {
+		if host, _, err := net.SplitHostPort(m.Target); err == nil {
+			return host
+		}
+	}
+	return m.Target
+}
@@ -0,0 +1,7 @@
+package model
+
+type TracerouteForm struct {
+	Target  string `json:"target,omitempty" minLength:"1"`
+	MaxHops int    `json:"max_hops,omitempty" validate:"optional"`
+	Count   int    `json:"count,omitempty" validate:"optional"`
+}
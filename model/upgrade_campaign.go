@@ -0,0 +1,163 @@
+package model
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	UpgradeCampaignRunning   = "running"
+	UpgradeCampaignPaused    = "paused"
+	UpgradeCampaignCompleted = "completed"
+	UpgradeCampaignCancelled = "cancelled"
+)
+
+const (
+	UpgradeServerPending   = "pending"   // 等待所在批次开始
+	UpgradeServerSent      = "sent"      // 已下发升级任务，等待 Agent 上报新版本
+	UpgradeServerSucceeded = "succeeded" // Agent 上报的版本与目标版本一致
+	UpgradeServerFailed    = "failed"    // Agent 上报失败或超时未确认
+	UpgradeServerSkipped   = "skipped"   // 下发时 Agent 未连接或任务已取消
+)
+
+const DefaultUpgradeTimeout = 600 // 等待 Agent 上报新版本的默认超时时间，秒
+
+// TaskUpgrade 升级任务，序列化后作为 pb.Task.Data 下发，pb.Task.Id 为升级计划 ID
+// Agent 升级失败时以相同的 Id 与任务类型上报失败结果，升级成功以 ReportSystemInfo 上报的版本确认
+type TaskUpgrade struct {
+	Version string `json:"version,omitempty"` // 目标版本，为空时升级到最新版本
+}
+
+// UpgradeCampaign Agent 分批升级计划
+type UpgradeCampaign struct {
+	Common
+	Name           string `json:"name"`
+	Version        string `json:"version"`          // 目标版本
+	CanaryPercent  uint8  `json:"canary_percent"`   // 第一批（金丝雀）服务器占比，0 时不单独划分
+	BatchSize      uint64 `json:"batch_size"`       // 之后每批的服务器数量，0 时剩余服务器为一批
+	Timeout        uint64 `json:"timeout"`          // 等待 Agent 上报新版本的超时时间，秒
+	PauseOnFailure bool   `json:"pause_on_failure"` // 有服务器升级失败时暂停计划
+	Status         string `json:"status"`
+}
+
+// UpgradeCampaignServer 升级计划中单台服务器的升级状态
+type UpgradeCampaignServer struct {
+	Common
+	CampaignID  uint64     `gorm:"index" json:"campaign_id"`
+	ServerID    uint64     `gorm:"index" json:"server_id"`
+	Wave        int        `json:"wave"` // 所在批次，从 0 开始
+	Status      string     `json:"status"`
+	FromVersion string     `json:"from_version,omitempty"` // 下发升级任务时的版本
+	Error       string     `json:"error,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished 服务器是否已结束本次升级
+func (s *UpgradeCampaignServer) Finished() bool {
+	return s.Status == UpgradeServerSucceeded || s.Status == UpgradeServerFailed || s.Status == UpgradeServerSkipped
+}
+
+// UpgradeVersionMatches 判断 Agent 上报的版本是否为目标版本，忽略 v 前缀
+func UpgradeVersionMatches(reported, target string) bool {
+	reported = strings.TrimPrefix(strings.TrimSpace(reported), "v")
+	target = strings.TrimPrefix(strings.TrimSpace(target), "v")
+	return reported != "" && reported == target
+}
+
+// AgentVersionAtLeast 判断 Agent 上报的版本是否不低于 min，忽略 v 前缀与预发布后缀，无法解析时返回 false
+func AgentVersionAtLeast(reported, min string) bool {
+	r, ok := parseAgentVersion(reported)
+	if !ok {
+		return false
+	}
+	m, ok := parseAgentVersion(min)
+	if !ok {
+		return false
+	}
+	return slices.Compare(r, m) >= 0
+}
+
+func parseAgentVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// PlanUpgradeWaves 将服务器划分为升级批次：第一批为金丝雀，之后每批 batchSize 台
+func PlanUpgradeWaves(servers []uint64, canaryPercent uint8, batchSize uint64) [][]uint64 {
+	var waves [][]uint64
+	rest := servers
+	if canaryPercent > 0 && len(rest) > 0 {
+		canary := (len(servers)*int(canaryPercent) + 99) / 100
+		if canary > len(rest) {
+			canary = len(rest)
+		}
+		waves = append(waves, rest[:canary])
+		rest = rest[canary:]
+	}
+	for len(rest) > 0 {
+		n := len(rest)
+		if batchSize > 0 && uint64(n) > batchSize {
+			n = int(batchSize)
+		}
+		waves = append(waves, rest[:n])
+		rest = rest[n:]
+	}
+	return waves
+}
+
+// UpgradeCampaignProgress 升级计划的进度
+type UpgradeCampaignProgress struct {
+	Total       int `json:"total"`
+	Pending     int `json:"pending"`
+	Sent        int `json:"sent"`
+	Succeeded   int `json:"succeeded"`
+	Failed      int `json:"failed"`
+	Skipped     int `json:"skipped"`
+	Waves       int `json:"waves"`
+	CurrentWave int `json:"current_wave"` // 当前进行中的批次，全部结束时等于 Waves
+}
+
+// NewUpgradeCampaignProgress 统计升级计划中各状态的服务器数量
+func NewUpgradeCampaignProgress(servers []*UpgradeCampaignServer) UpgradeCampaignProgress {
+	p := UpgradeCampaignProgress{Total: len(servers), CurrentWave: -1}
+	for _, s := range servers {
+		switch s.Status {
+		case UpgradeServerPending:
+			p.Pending++
+		case UpgradeServerSent:
+			p.Sent++
+		case UpgradeServerSucceeded:
+			p.Succeeded++
+		case UpgradeServerFailed:
+			p.Failed++
+		case UpgradeServerSkipped:
+			p.Skipped++
+		}
+		if s.Wave+1 > p.Waves {
+			p.Waves = s.Wave + 1
+		}
+		if !s.Finished() && (p.CurrentWave == -1 || s.Wave < p.CurrentWave) {
+			p.CurrentWave = s.Wave
+		}
+	}
+	if p.CurrentWave == -1 {
+		p.CurrentWave = p.Waves
+	}
+	return p
+}
@@ -0,0 +1,18 @@
+package model
+
+type UpgradeCampaignForm struct {
+	Name           string   `json:"name,omitempty" minLength:"1"`
+	Version        string   `json:"version,omitempty" minLength:"1"`                     // 目标版本
+	Servers        []uint64 `json:"servers,omitempty" validate:"optional"`               // 升级的服务器
+	ServerGroups   []uint64 `json:"server_groups,omitempty" validate:"optional"`         // 升级的服务器分组，与 Servers 合并
+	CanaryPercent  uint8    `json:"canary_percent,omitempty" validate:"optional"`        // 第一批（金丝雀）服务器占比，0-100
+	BatchSize      uint64   `json:"batch_size,omitempty" validate:"optional"`            // 之后每批的服务器数量，0 时剩余服务器为一批
+	Timeout        uint64   `json:"timeout,omitempty" validate:"optional" default:"600"` // 等待 Agent 上报新版本的超时时间，秒
+	PauseOnFailure bool     `json:"pause_on_failure,omitempty" validate:"optional"`      // 有服务器升级失败时暂停计划
+}
+
+type UpgradeCampaignResponseItem struct {
+	Campaign UpgradeCampaign          `json:"campaign"`
+	Progress UpgradeCampaignProgress  `json:"progress"`
+	Servers  []*UpgradeCampaignServer `json:"servers,omitempty"`
+}
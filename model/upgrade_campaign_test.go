@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPlanUpgradeWaves(t *testing.T) {
+	servers := []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	cases := []struct {
+		canary   uint8
+		batch    uint64
+		expected string
+	}{
+		{0, 0, "[[1 2 3 4 5 6 7 8 9 10]]"},
+		{10, 0, "[[1] [2 3 4 5 6 7 8 9 10]]"},
+		{15, 4, "[[1 2] [3 4 5 6] [7 8 9 10]]"},
+		{100, 3, "[[1 2 3 4 5 6 7 8 9 10]]"},
+		{0, 3, "[[1 2 3] [4 5 6] [7 8 9] [10]]"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(PlanUpgradeWaves(servers, c.canary, c.batch)); got != c.expected {
+			t.Fatalf("Expected %s for canary %d batch %d, but got %s", c.expected, c.canary, c.batch, got)
+		}
+	}
+	if got := PlanUpgradeWaves(nil, 10, 1); len(got) != 0 {
+		t.Fatalf("Expected no waves for no servers, but got %v", got)
+	}
+}
+
+func TestUpgradeVersionMatches(t *testing.T) {
+	cases := []struct {
+		reported, target string
+		expected         bool
+	}{
+		{"1.2.3", "v1.2.3", true},
+		{"v1.2.3", "1.2.3", true},
+		{"1.2.2", "1.2.3", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		if got := UpgradeVersionMatches(c.reported, c.target); got != c.expected {
+			t.Fatalf("Expected %v for %q and %q, but got %v", c.expected, c.reported, c.target, got)
+		}
+	}
+}
+
+func TestAgentVersionAtLeast(t *testing.T) {
+	cases := []struct {
+		reported, min string
+		expected      bool
+	}{
+		{"1.10.0", "1.10.0", true},
+		{"v1.12.3", "1.10.0", true},
+		{"1.9.9", "1.10.0", false},
+		{"2.0.0-beta", "1.10.0", true},
+		{"debug", "1.10.0", false},
+		{"", "1.10.0", false},
+	}
+	for _, c := range cases {
+		if got := AgentVersionAtLeast(c.reported, c.min); got != c.expected {
+			t.Fatalf("Expected %v for %q and %q, but got %v", c.expected, c.reported, c.min, got)
+		}
+	}
+}
+
+func TestUpgradeCampaignProgress(t *testing.T) {
+	servers := []*UpgradeCampaignServer{
+		{Wave: 0, Status: UpgradeServerSucceeded},
+		{Wave: 1, Status: UpgradeServerSent},
+		{Wave: 1, Status: UpgradeServerFailed},
+		{Wave: 2, Status: UpgradeServerPending},
+	}
+	p := NewUpgradeCampaignProgress(servers)
+	if p.Total != 4 || p.Succeeded != 1 || p.Sent != 1 || p.Failed != 1 || p.Pending != 1 {
+		t.Fatalf("Expected counts 4/1/1/1/1, but got %+v", p)
+	}
+	if p.Waves != 3 || p.CurrentWave != 1 {
+		t.Fatalf("Expected wave 1 of 3, but got %d of %d", p.CurrentWave, p.Waves)
+	}
+
+	servers[1].Status = UpgradeServerSucceeded
+	servers[3].Status = UpgradeServerSkipped
+	if p := NewUpgradeCampaignProgress(servers); p.CurrentWave != p.Waves {
+		t.Fatalf("Expected all waves finished, but got wave %d of %d", p.CurrentWave, p.Waves)
+	}
+}
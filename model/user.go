@@ -2,7 +2,7 @@ package model
 
 type User struct {
 	Common
-	Username string `json:"username,omitempty" gorm:"uniqueIndex"`
+	Username string `json:"username,omitempty" gorm:"uniqueIndex;size:191"`
 	Password string `json:"password,omitempty" gorm:"type:char(72)"`
 }
 
@@ -24,10 +24,10 @@ type WAFApiMock struct {
 }
 
 type WAF struct {
-	IP                 []byte `gorm:"type:binary(16);primaryKey" json:"ip,omitempty"`
-	Count              uint64 `json:"count,omitempty"`
-	LastBlockReason    uint8  `json:"last_block_reason,omitempty"`
-	LastBlockTimestamp uint64 `json:"last_block_timestamp,omitempty"`
+	IP                 BinaryIP `gorm:"primaryKey" json:"ip,omitempty"`
+	Count              uint64   `json:"count,omitempty"`
+	LastBlockReason    uint8    `json:"last_block_reason,omitempty"`
+	LastBlockTimestamp uint64   `json:"last_block_timestamp,omitempty"`
 }
 
 func (w *WAF) TableName() string {
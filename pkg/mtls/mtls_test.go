@@ -0,0 +1,129 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func newTestCA(t *testing.T) *CA {
+	certPEM, keyPEM, err := NewCA("test ca", time.Hour)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	ca, err := ParseCA(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	return ca
+}
+
+// handshake 在本地建立一次双向 TLS 连接，返回服务端看到的客户端证书
+func handshake(t *testing.T, serverCA *CA, clientCert tls.Certificate) (*x509.Certificate, error) {
+	serverCert, err := serverCA.IssueServerCert([]string{"127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverCA.ServerTLSConfig(serverCert))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	defer l.Close()
+
+	type result struct {
+		cert *x509.Certificate
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		if err := tc.Handshake(); err != nil {
+			ch <- result{err: err}
+			return
+		}
+		ch <- result{cert: tc.ConnectionState().PeerCertificates[0]}
+	}()
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      serverCA.Pool(),
+		ServerName:   "127.0.0.1",
+	})
+	if err == nil {
+		conn.Handshake()
+		conn.Close()
+	}
+	r := <-ch
+	return r.cert, r.err
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+
+	certPEM, keyPEM, cert, err := ca.IssueClientCert("agent-uuid", time.Hour)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	peer, err := handshake(t, ca, clientCert)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if peer.Subject.CommonName != "agent-uuid" || Serial(peer) != Serial(cert) {
+		t.Fatalf("Expected agent-uuid %s, but got %s %s", Serial(cert), peer.Subject.CommonName, Serial(peer))
+	}
+
+	// 其他 CA 签发的证书无法通过校验
+	otherCertPEM, otherKeyPEM, _, err := newTestCA(t).IssueClientCert("agent-uuid", time.Hour)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	otherCert, err := tls.X509KeyPair(otherCertPEM, otherKeyPEM)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if _, err := handshake(t, ca, otherCert); err == nil {
+		t.Fatalf("Expected handshake error, but got nil")
+	}
+}
+
+func TestSignCSR(t *testing.T) {
+	ca := newTestCA(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "spoofed"}}, key)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	_, cert, err := ca.SignCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), "agent-uuid", time.Hour)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if cert.Subject.CommonName != "agent-uuid" {
+		t.Fatalf("Expected agent-uuid, but got %s", cert.Subject.CommonName)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: ca.Pool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	if _, _, err := ca.SignCSR([]byte("invalid"), "agent-uuid", time.Hour); err == nil {
+		t.Fatalf("Expected error, but got nil")
+	}
+}
@@ -2,24 +2,49 @@ package rpc
 
 import (
 	"context"
+	"errors"
+	"log"
 	"strings"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/hashicorp/go-uuid"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/nezhahq/nezha/model"
 	"github.com/nezhahq/nezha/service/singleton"
 )
 
+// errPendingApproval 开启审核后新 Agent 在审核通过前无法上报数据
+var errPendingApproval = status.Error(codes.PermissionDenied, "服务器等待审核")
+
 type authHandler struct {
 	ClientSecret string
 	ClientUUID   string
 }
 
+// Check 校验 Agent 身份：
+// 通过双向 TLS 连接的 Agent 使用客户端证书认证；
+// 已设置独立密钥的服务器只接受该密钥，密钥被吊销后只接受注册令牌；
+// 未设置独立密钥的服务器只接受 AgentSecretKey（未禁止自动注册时可注册新服务器，开启审核时进入审核队列），
+// 注册令牌只能注册尚不存在的服务器或重新注册独立密钥已被吊销的服务器，
+// 使用注册令牌注册的服务器无需审核，注册后通过响应头 client_secret 下发独立密钥，
+// 开启双向 TLS 且 Agent 提交了 client_csr-bin 证书请求时通过响应头 client_certificate-bin 下发客户端证书
 func (a *authHandler) Check(ctx context.Context) (uint64, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+			clientID, err := singleton.VerifyAgentCertificate(tlsInfo.State.VerifiedChains[0][0])
+			if err != nil {
+				return 0, status.Error(codes.Unauthenticated, "客户端证书认证失败")
+			}
+			return clientID, nil
+		}
+	}
+
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return 0, status.Errorf(codes.Unauthenticated, "获取 metaData 失败")
@@ -36,13 +61,6 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 
 	ip, _ := ctx.Value(model.CtxKeyRealIP{}).(string)
 
-	if clientSecret != singleton.Conf.AgentSecretKey {
-		model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail)
-		return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
-	}
-
-	model.ClearIP(singleton.DB, ip)
-
 	var clientUUID string
 	if value, ok := md["client_uuid"]; ok {
 		clientUUID = value[0]
@@ -53,23 +71,111 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 	}
 
 	singleton.ServerLock.RLock()
-	defer singleton.ServerLock.RUnlock()
-
 	clientID, hasID := singleton.ServerUUIDToID[clientUUID]
-	if !hasID {
-		s := model.Server{UUID: clientUUID, Name: petname.Generate(2, "-")}
-		if err := singleton.DB.Create(&s).Error; err != nil {
+	var server *model.Server
+	if hasID {
+		server = singleton.ServerList[clientID]
+	}
+	singleton.ServerLock.RUnlock()
+
+	var cred *model.ServerCredential
+	if hasID {
+		cred = singleton.GetServerCredential(clientID)
+	}
+
+	switch {
+	case cred != nil && !cred.Revoked():
+		if !cred.Verify(clientSecret) {
+			model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail)
+			return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+		}
+	case cred == nil && clientSecret == singleton.Conf.AgentSecretKey && (hasID || !singleton.Conf.DisableAgentAutoRegister):
+		if !hasID && singleton.Conf.AgentApprovalRequired {
+			if err := singleton.QueuePendingServer(clientUUID, ip); err != nil {
+				if errors.Is(err, singleton.ErrServerRejected) {
+					model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail)
+					return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+				}
+				return 0, status.Error(codes.Unavailable, err.Error())
+			}
+			return 0, errPendingApproval
+		}
+		if !hasID {
+			// generate a random silly server name
+			s := model.Server{UUID: clientUUID, Name: petname.Generate(2, "-")}
+			if err := singleton.DB.Create(&s).Error; err != nil {
+				return 0, status.Error(codes.Unauthenticated, err.Error())
+			}
+			singleton.AddServer(&s)
+			clientID = s.ID
+		}
+	default:
+		s, secret, err := singleton.EnrollServer(clientSecret, clientUUID, server)
+		if errors.Is(err, singleton.ErrInvalidEnrollmentToken) || errors.Is(err, singleton.ErrEnrollmentNotAllowed) {
+			model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail)
+			return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+		}
+		if err != nil {
 			return 0, status.Error(codes.Unauthenticated, err.Error())
 		}
-		s.Host = &model.Host{}
-		s.State = &model.HostState{}
-		s.GeoIP = &model.GeoIP{}
-		// generate a random silly server name
-		singleton.ServerList[s.ID] = &s
-		singleton.ServerUUIDToID[clientUUID] = s.ID
-		singleton.ReSortServer()
+		header := metadata.Pairs("client_secret", secret)
+		if csr, ok := md["client_csr-bin"]; ok && singleton.AgentCA != nil {
+			// 令牌已被使用，证书签发失败时仍需下发密钥，之后可通过接口重新签发证书
+			if certPEM, _, err := singleton.IssueAgentCertificate(s.ID, s.UUID, []byte(csr[0])); err != nil {
+				log.Printf("NEZHA>> IssueAgentCertificate error: %v, clientID: %d\n", err, s.ID)
+			} else {
+				header.Append("client_certificate-bin", string(certPEM))
+				header.Append("ca_certificate-bin", string(singleton.AgentCA.CertPEM))
+			}
+		}
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			return 0, status.Error(codes.Internal, err.Error())
+		}
+		if hasID {
+			singleton.ServerLock.Lock()
+			server.Labels = s.Labels
+			singleton.ServerLock.Unlock()
+		} else {
+			singleton.AddServer(s)
+		}
 		clientID = s.ID
 	}
 
+	model.ClearIP(singleton.DB, ip)
+
 	return clientID, nil
 }
+
+func clientUUIDFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if value, ok := md["client_uuid"]; ok {
+		return value[0]
+	}
+	return ""
+}
+
+// serveAgentStream 运行 serve 直到其返回，服务器凭据被吊销或重置时立即结束流
+func serveAgentStream(ctx context.Context, clientID uint64, serve func() error) error {
+	ctx, untrack := singleton.TrackAgentStream(ctx, clientID)
+	defer untrack()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), singleton.ErrAgentCredentialRevoked) {
+			// 返回后 gRPC 取消流的 context，serve 中阻塞的 Recv 随之返回
+			log.Printf("NEZHA>> 服务器凭据已失效，断开 Agent 连接，clientID: %d\n", clientID)
+			return status.Error(codes.Unauthenticated, "客户端凭据已失效")
+		}
+		return <-errCh
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/nezhahq/nezha/pkg/ddns"
 	geoipx "github.com/nezhahq/nezha/pkg/geoip"
 	"github.com/nezhahq/nezha/pkg/grpcx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/nezhahq/nezha/model"
 	pb "github.com/nezhahq/nezha/proto"
@@ -43,48 +45,70 @@ func (s *NezhaHandler) RequestTask(stream pb.NezhaService_RequestTaskServer) err
 		return err
 	}
 
-	singleton.ServerLock.RLock()
-	singleton.ServerList[clientID].TaskStream = stream
-	singleton.ServerLock.RUnlock()
+	return serveAgentStream(stream.Context(), clientID, func() error {
+		singleton.ServerLock.RLock()
+		singleton.ServerList[clientID].TaskStream = stream
+		hasWatchedUnits := len(singleton.ServerList[clientID].WatchedUnits) > 0
+		singleton.ServerLock.RUnlock()
 
-	var result *pb.TaskResult
-	for {
-		result, err = stream.Recv()
-		if err != nil {
-			log.Printf("NEZHA>> RequestTask error: %v, clientID: %d\n", err, clientID)
-			return nil
+		if hasWatchedUnits {
+			if err := singleton.PushWatchedUnits(clientID); err != nil {
+				log.Printf("NEZHA>> PushWatchedUnits error: %v, clientID: %d\n", err, clientID)
+			}
 		}
-		if result.GetType() == model.TaskTypeCommand {
-			// 处理上报的计划任务
-			singleton.CronLock.RLock()
-			cr := singleton.Crons[result.GetId()]
-			singleton.CronLock.RUnlock()
-			if cr != nil {
-				// 保存当前服务器状态信息
-				var curServer model.Server
-				singleton.ServerLock.RLock()
-				copier.Copy(&curServer, singleton.ServerList[clientID])
-				singleton.ServerLock.RUnlock()
-				if cr.PushSuccessful && result.GetSuccessful() {
-					singleton.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Successfully"),
-						cr.Name, singleton.ServerList[clientID].Name, result.GetData()), nil, &curServer)
-				}
-				if !result.GetSuccessful() {
-					singleton.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Failed"),
-						cr.Name, singleton.ServerList[clientID].Name, result.GetData()), nil, &curServer)
+		if err := singleton.PushAgentConfig(clientID); err != nil {
+			log.Printf("NEZHA>> PushAgentConfig error: %v, clientID: %d\n", err, clientID)
+		}
+
+		var result *pb.TaskResult
+		for {
+			result, err = stream.Recv()
+			if err != nil {
+				log.Printf("NEZHA>> RequestTask error: %v, clientID: %d\n", err, clientID)
+				return nil
+			}
+			if result.GetType() == model.TaskTypeCommand {
+				// 处理上报的计划任务
+				singleton.CronLock.RLock()
+				cr := singleton.Crons[result.GetId()]
+				singleton.CronLock.RUnlock()
+				if cr != nil {
+					// 保存当前服务器状态信息
+					var curServer model.Server
+					singleton.ServerLock.RLock()
+					copier.Copy(&curServer, singleton.ServerList[clientID])
+					singleton.ServerLock.RUnlock()
+					if cr.PushSuccessful && result.GetSuccessful() {
+						singleton.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Successfully"),
+							cr.Name, singleton.ServerList[clientID].Name, result.GetData()), nil, &curServer)
+					}
+					if !result.GetSuccessful() {
+						singleton.SendNotification(cr.NotificationGroupID, fmt.Sprintf("[%s] %s, %s\n%s", singleton.Localizer.T("Scheduled Task Executed Failed"),
+							cr.Name, singleton.ServerList[clientID].Name, result.GetData()), nil, &curServer)
+					}
+					singleton.DB.Model(cr).Updates(model.Cron{
+						LastExecutedAt: time.Now().Add(time.Second * -1 * time.Duration(result.GetDelay())),
+						LastResult:     result.GetSuccessful(),
+					})
 				}
-				singleton.DB.Model(cr).Updates(model.Cron{
-					LastExecutedAt: time.Now().Add(time.Second * -1 * time.Duration(result.GetDelay())),
-					LastResult:     result.GetSuccessful(),
+			} else if result.GetType() == model.TaskTypeTraceroute {
+				singleton.OnTracerouteResult(clientID, result)
+			} else if result.GetType() == model.TaskTypeTopProcesses {
+				singleton.OnTopProcessesResult(clientID, result)
+			} else if result.GetType() == model.TaskTypeSystemdUnits {
+				singleton.OnSystemdUnitsResult(clientID, result)
+			} else if result.GetType() == model.TaskTypeAgentConfig {
+				singleton.OnAgentConfigResult(clientID, result)
+			} else if result.GetType() == model.TaskTypeUpgrade {
+				singleton.OnUpgradeResult(clientID, result)
+			} else if model.IsServiceSentinelNeeded(result.GetType()) {
+				singleton.ServiceSentinelShared.Dispatch(singleton.ReportData{
+					Data:     result,
+					Reporter: clientID,
 				})
 			}
-		} else if model.IsServiceSentinelNeeded(result.GetType()) {
-			singleton.ServiceSentinelShared.Dispatch(singleton.ReportData{
-				Data:     result,
-				Reporter: clientID,
-			})
 		}
-	}
+	})
 }
 
 func (s *NezhaHandler) ReportSystemState(stream pb.NezhaService_ReportSystemStateServer) error {
@@ -93,42 +117,50 @@ func (s *NezhaHandler) ReportSystemState(stream pb.NezhaService_ReportSystemStat
 	if clientID, err = s.Auth.Check(stream.Context()); err != nil {
 		return err
 	}
-	var state *pb.State
-	for {
-		state, err = stream.Recv()
-		if err != nil {
-			log.Printf("NEZHA>> ReportSystemState eror: %v, clientID: %d\n", err, clientID)
-			return nil
-		}
-		state := model.PB2State(state)
+	return serveAgentStream(stream.Context(), clientID, func() error {
+		var state *pb.State
+		for {
+			state, err = stream.Recv()
+			if err != nil {
+				log.Printf("NEZHA>> ReportSystemState eror: %v, clientID: %d\n", err, clientID)
+				return nil
+			}
+			state := model.PB2State(state)
 
-		singleton.ServerLock.RLock()
+			singleton.ServerLock.RLock()
 
-		if singleton.ServerList[clientID] == nil {
+			if singleton.ServerList[clientID] == nil {
+				singleton.ServerLock.RUnlock()
+				return nil
+			}
+
+			singleton.ServerList[clientID].LastActive = time.Now()
+			singleton.ServerList[clientID].State = &state
+			// 应对 dashboard 重启的情况，如果从未记录过，先打点，等到小时时间点时入库
+			if singleton.ServerList[clientID].PrevTransferInSnapshot == 0 || singleton.ServerList[clientID].PrevTransferOutSnapshot == 0 {
+				singleton.ServerList[clientID].PrevTransferInSnapshot = int64(state.NetInTransfer)
+				singleton.ServerList[clientID].PrevTransferOutSnapshot = int64(state.NetOutTransfer)
+			}
 			singleton.ServerLock.RUnlock()
-			return nil
-		}
 
-		singleton.ServerList[clientID].LastActive = time.Now()
-		singleton.ServerList[clientID].State = &state
-		// 应对 dashboard 重启的情况，如果从未记录过，先打点，等到小时时间点时入库
-		if singleton.ServerList[clientID].PrevTransferInSnapshot == 0 || singleton.ServerList[clientID].PrevTransferOutSnapshot == 0 {
-			singleton.ServerList[clientID].PrevTransferInSnapshot = int64(state.NetInTransfer)
-			singleton.ServerList[clientID].PrevTransferOutSnapshot = int64(state.NetOutTransfer)
+			stream.Send(&pb.Receipt{Proced: true})
 		}
-		singleton.ServerLock.RUnlock()
-
-		stream.Send(&pb.Receipt{Proced: true})
-	}
+	})
 }
 
 func (s *NezhaHandler) onReportSystemInfo(c context.Context, r *pb.Host) error {
 	var clientID uint64
 	var err error
+	host := model.PB2Host(r)
 	if clientID, err = s.Auth.Check(c); err != nil {
+		if err == errPendingApproval {
+			// 记录待审核 Agent 的主机信息供管理员审核
+			if err := singleton.UpdatePendingServerHost(clientUUIDFromContext(c), &host); err != nil {
+				log.Printf("NEZHA>> UpdatePendingServerHost error: %v\n", err)
+			}
+		}
 		return err
 	}
-	host := model.PB2Host(r)
 	singleton.ServerLock.RLock()
 	defer singleton.ServerLock.RUnlock()
 
@@ -156,10 +188,33 @@ func (s *NezhaHandler) ReportSystemInfo2(c context.Context, r *pb.Host) (*pb.Uin
 	return &pb.Uint64Receipt{Data: singleton.DashboardBootTime}, nil
 }
 
+func (s *NezhaHandler) ReportContainers(c context.Context, r *pb.ContainerList) (*pb.Receipt, error) {
+	clientID, err := s.Auth.Check(c)
+	if err != nil {
+		return nil, err
+	}
+
+	singleton.ServerLock.RLock()
+	defer singleton.ServerLock.RUnlock()
+	server := singleton.ServerList[clientID]
+	if server == nil {
+		return nil, status.Error(codes.NotFound, "服务器不存在")
+	}
+	server.Containers = model.PB2ContainerSnapshot(server.Containers, r, time.Now())
+	return &pb.Receipt{Proced: true}, nil
+}
+
 func (s *NezhaHandler) IOStream(stream pb.NezhaService_IOStreamServer) error {
-	if _, err := s.Auth.Check(stream.Context()); err != nil {
+	clientID, err := s.Auth.Check(stream.Context())
+	if err != nil {
 		return err
 	}
+	return serveAgentStream(stream.Context(), clientID, func() error {
+		return s.serveIOStream(stream)
+	})
+}
+
+func (s *NezhaHandler) serveIOStream(stream pb.NezhaService_IOStreamServer) error {
 	id, err := stream.Recv()
 	if err != nil {
 		return err
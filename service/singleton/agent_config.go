@@ -0,0 +1,106 @@
+package singleton
+
+import (
+	"log"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// EffectiveAgentConfig 返回服务器生效的 Agent 配置：按分组 ID 依次合并分组配置，再合并服务器自身的配置
+func EffectiveAgentConfig(serverID uint64) (*model.AgentConfig, error) {
+	ServerLock.RLock()
+	server, ok := ServerList[serverID]
+	var serverConfig *model.AgentConfig
+	if ok {
+		serverConfig = server.AgentConfig
+	}
+	ServerLock.RUnlock()
+	if !ok {
+		return nil, Localizer.ErrorT("server id %d does not exist", serverID)
+	}
+
+	var groups []model.ServerGroup
+	if err := DB.Where("id IN (?) AND agent_config_raw <> ''",
+		DB.Model(&model.ServerGroupServer{}).Select("server_group_id").Where("server_id = ?", serverID)).
+		Order("id").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	var config model.AgentConfig
+	for _, g := range groups {
+		config = config.Merge(g.AgentConfig)
+	}
+	config = config.Merge(serverConfig)
+	return &config, nil
+}
+
+// PushAgentConfig 向服务器下发生效的 Agent 配置，Agent 未连接时跳过，连接后会重新下发
+func PushAgentConfig(serverID uint64) error {
+	config, err := EffectiveAgentConfig(serverID)
+	if err != nil {
+		return err
+	}
+	version := config.Version()
+
+	ServerLock.RLock()
+	var stream pb.NezhaService_RequestTaskServer
+	var applied uint64
+	if server, ok := ServerList[serverID]; ok {
+		stream = server.TaskStream
+		applied = server.AgentConfigVersion
+	}
+	ServerLock.RUnlock()
+	// 从未下发过配置且当前也没有配置
+	if stream == nil || (version == 0 && applied == 0) {
+		return nil
+	}
+
+	data, err := utils.Json.Marshal(&model.TaskAgentConfig{Version: version, Config: config})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.Task{
+		Id:   version,
+		Type: model.TaskTypeAgentConfig,
+		Data: string(data),
+	})
+}
+
+// PushAgentConfigs 向多台服务器下发 Agent 配置
+func PushAgentConfigs(serverIDs []uint64) {
+	for _, id := range serverIDs {
+		if err := PushAgentConfig(id); err != nil {
+			log.Printf("NEZHA>> Agent 配置下发失败：%v, serverID: %d\n", err, id)
+		}
+	}
+}
+
+// ServerGroupMembers 返回分组内的服务器
+func ServerGroupMembers(groupIDs []uint64) ([]uint64, error) {
+	var ids []uint64
+	if err := DB.Model(&model.ServerGroupServer{}).Where("server_group_id IN (?)", groupIDs).
+		Distinct().Pluck("server_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// OnAgentConfigResult 记录 Agent 确认应用的配置版本
+func OnAgentConfigResult(serverID uint64, r *pb.TaskResult) {
+	if !r.GetSuccessful() {
+		log.Printf("NEZHA>> Agent 配置应用失败：%s, version: %d, serverID: %d\n", r.GetData(), r.GetId(), serverID)
+		return
+	}
+
+	ServerLock.RLock()
+	if server, ok := ServerList[serverID]; ok {
+		server.AgentConfigVersion = r.GetId()
+	}
+	ServerLock.RUnlock()
+
+	if err := DB.Model(&model.Server{}).Where("id = ?", serverID).Update("agent_config_version", r.GetId()).Error; err != nil {
+		log.Println("NEZHA>> Agent 配置版本持久化失败：", err)
+	}
+}
@@ -0,0 +1,134 @@
+package singleton
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/mtls"
+)
+
+var (
+	AgentCA *mtls.CA // 开启 Agent 双向 TLS 后的内置 CA
+
+	ErrAgentMTLSDisabled       = errors.New("agent mtls is disabled")
+	ErrInvalidAgentCertificate = errors.New("invalid agent certificate")
+)
+
+// InitAgentMTLS 加载内置 CA，首次开启时生成 CA 并写入配置文件
+func InitAgentMTLS() error {
+	if !Conf.AgentMTLS.Enabled {
+		return nil
+	}
+	if Conf.AgentMTLS.CACert == "" || Conf.AgentMTLS.CAKey == "" {
+		certPEM, keyPEM, err := mtls.NewCA("Nezha Agent CA", time.Hour*24*365*10)
+		if err != nil {
+			return err
+		}
+		Conf.AgentMTLS.CACert, Conf.AgentMTLS.CAKey = string(certPEM), string(keyPEM)
+		if err := Conf.Save(); err != nil {
+			return err
+		}
+	}
+	ca, err := mtls.ParseCA([]byte(Conf.AgentMTLS.CACert), []byte(Conf.AgentMTLS.CAKey))
+	if err != nil {
+		return err
+	}
+	AgentCA = ca
+	return nil
+}
+
+// AgentTLSConfig 返回双向 TLS gRPC 服务使用的配置，服务端证书在每次启动时由内置 CA 签发
+func AgentTLSConfig() (*tls.Config, error) {
+	if AgentCA == nil {
+		return nil, ErrAgentMTLSDisabled
+	}
+	names := Conf.AgentMTLS.ServerNames
+	if len(names) == 0 && Conf.InstallHost != "" {
+		host, _, err := net.SplitHostPort(Conf.InstallHost)
+		if err != nil {
+			host = Conf.InstallHost
+		}
+		names = append(names, host)
+	}
+	if len(names) == 0 {
+		names = []string{"localhost", "127.0.0.1"}
+	}
+	cert, err := AgentCA.IssueServerCert(names, time.Hour*24*365)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := AgentCA.ServerTLSConfig(cert)
+	// 握手时拒绝已吊销的证书
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return ErrInvalidAgentCertificate
+		}
+		_, err := VerifyAgentCertificate(cs.PeerCertificates[0])
+		return err
+	}
+	return tlsConfig, nil
+}
+
+// IssueAgentCertificate 为服务器签发客户端证书，csrPEM 为空时由面板生成私钥并一同返回
+func IssueAgentCertificate(serverID uint64, uuid string, csrPEM []byte) (certPEM, keyPEM []byte, err error) {
+	if AgentCA == nil {
+		return nil, nil, ErrAgentMTLSDisabled
+	}
+	validity := time.Hour * 24 * time.Duration(Conf.AgentMTLS.ClientCertDays)
+	var cert *x509.Certificate
+	if len(csrPEM) > 0 {
+		certPEM, cert, err = AgentCA.SignCSR(csrPEM, uuid, validity)
+	} else {
+		certPEM, keyPEM, cert, err = AgentCA.IssueClientCert(uuid, validity)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := DB.Create(&model.AgentCertificate{
+		ServerID: serverID,
+		Serial:   mtls.Serial(cert),
+		NotAfter: cert.NotAfter,
+	}).Error; err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+// VerifyAgentCertificate 根据已通过 TLS 校验的客户端证书查找服务器，证书需未被吊销且与服务器 UUID 一致
+func VerifyAgentCertificate(cert *x509.Certificate) (uint64, error) {
+	var c model.AgentCertificate
+	result := DB.Where("serial = ?", mtls.Serial(cert)).Limit(1).Find(&c)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 || c.Revoked() {
+		return 0, ErrInvalidAgentCertificate
+	}
+
+	ServerLock.RLock()
+	defer ServerLock.RUnlock()
+	server, ok := ServerList[c.ServerID]
+	if !ok || server.UUID != cert.Subject.CommonName {
+		return 0, ErrInvalidAgentCertificate
+	}
+	return c.ServerID, nil
+}
+
+// RevokeAgentCertificates 吊销客户端证书并断开所属服务器已建立的连接，使用该证书的 Agent 将无法再建立连接
+func RevokeAgentCertificates(ids []uint64) error {
+	var serverIDs []uint64
+	if err := DB.Model(&model.AgentCertificate{}).Where("id in (?) AND revoked_at IS NULL", ids).
+		Distinct().Pluck("server_id", &serverIDs).Error; err != nil {
+		return err
+	}
+	if err := DB.Model(&model.AgentCertificate{}).Where("id in (?) AND revoked_at IS NULL", ids).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	CloseAgentStreams(serverIDs)
+	return nil
+}
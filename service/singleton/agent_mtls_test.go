@@ -0,0 +1,45 @@
+package singleton
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestRevokeAgentCertificatesClosesStreams(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+
+	server := model.Server{UUID: "5e2b7c91-4f0a-4d3e-9b6c-8a1d2e7f3c40"}
+	if err := DB.Create(&server).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	loadServers()
+
+	cert := model.AgentCertificate{ServerID: server.ID, Serial: "01", NotAfter: time.Now().AddDate(1, 0, 0)}
+	if err := DB.Create(&cert).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	ctx, untrack := TrackAgentStream(context.Background(), server.ID)
+	defer untrack()
+
+	if err := RevokeAgentCertificates([]uint64{cert.ID}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected stream to be closed, but got open")
+	}
+
+	if err := DB.First(&cert, cert.ID).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if !cert.Revoked() {
+		t.Fatalf("Expected certificate to be revoked, but got not revoked")
+	}
+}
@@ -0,0 +1,62 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrAgentCredentialRevoked 服务器凭据被吊销或重置后已建立的 Agent 流以此原因断开
+var ErrAgentCredentialRevoked = errors.New("agent credential revoked")
+
+type agentStream struct {
+	cancel context.CancelCauseFunc
+}
+
+var (
+	agentStreams     = make(map[uint64]map[*agentStream]struct{})
+	agentStreamsLock sync.Mutex
+)
+
+// TrackAgentStream 登记服务器已通过认证的 Agent 流，返回的 context 在服务器凭据被吊销或重置时取消，
+// 流结束后需调用返回的 untrack
+func TrackAgentStream(ctx context.Context, serverID uint64) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	as := &agentStream{cancel: cancel}
+
+	agentStreamsLock.Lock()
+	if agentStreams[serverID] == nil {
+		agentStreams[serverID] = make(map[*agentStream]struct{})
+	}
+	agentStreams[serverID][as] = struct{}{}
+	agentStreamsLock.Unlock()
+
+	return ctx, func() {
+		agentStreamsLock.Lock()
+		delete(agentStreams[serverID], as)
+		if len(agentStreams[serverID]) == 0 {
+			delete(agentStreams, serverID)
+		}
+		agentStreamsLock.Unlock()
+		cancel(nil)
+	}
+}
+
+// CloseAgentStreams 断开服务器已建立的全部 Agent 流，并清除任务下发通道
+func CloseAgentStreams(serverIDs []uint64) {
+	agentStreamsLock.Lock()
+	for _, id := range serverIDs {
+		for as := range agentStreams[id] {
+			as.cancel(ErrAgentCredentialRevoked)
+		}
+	}
+	agentStreamsLock.Unlock()
+
+	ServerLock.Lock()
+	for _, id := range serverIDs {
+		if server, ok := ServerList[id]; ok {
+			server.TaskStream = nil
+		}
+	}
+	ServerLock.Unlock()
+}
@@ -155,11 +155,18 @@ func checkStatus() {
 			if !passed {
 				// 始终触发模式或上次检查不为失败时触发报警（跳过单次触发+上次失败的情况）
 				if alert.TriggerMode == model.ModeAlwaysTrigger || alertsPrevState[alert.ID][server.ID] != _RuleCheckFail {
+					firstTrigger := alertsPrevState[alert.ID][server.ID] != _RuleCheckFail
 					alertsPrevState[alert.ID][server.ID] = _RuleCheckFail
 					message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Incident"),
 						server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
 					go SendTriggerTasks(alert.FailTriggerTasks, curServer.ID)
-					go SendNotification(alert.NotificationGroupID, message, NotificationMuteLabel.ServerIncident(server.ID, alert.ID), &curServer)
+					if firstTrigger {
+						// 状态由正常转为失败时记录报警事件
+						eventID := createAlertEvent(alert.ID, server.ID, message)
+						go notifyAlertIncident(alert.ID, eventID, alert.NotificationGroupID, alert.NeedsTopProcesses(), message, &curServer)
+					} else {
+						go SendNotification(alert.NotificationGroupID, message, NotificationMuteLabel.ServerIncident(server.ID, alert.ID), &curServer)
+					}
 					// 清除恢复通知的静音缓存
 					UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID))
 				}
@@ -169,6 +176,7 @@ func checkStatus() {
 					message := fmt.Sprintf("[%s] %s(%s) %s", Localizer.T("Resolved"),
 						server.Name, IPDesensitize(server.GeoIP.IP.Join()), alert.Name)
 					go SendTriggerTasks(alert.RecoverTriggerTasks, curServer.ID)
+					resolveAlertEvents(alert.ID, server.ID)
 					go SendNotification(alert.NotificationGroupID, message, NotificationMuteLabel.ServerIncidentResolved(server.ID, alert.ID), &curServer)
 					// 清除失败通知的静音缓存
 					UnMuteNotification(alert.NotificationGroupID, NotificationMuteLabel.ServerIncident(server.ID, alert.ID))
@@ -0,0 +1,134 @@
+package singleton
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+// ExportConfig 从数据库导出全部配置项，secrets 为 false 时不导出心跳监控的上报密钥
+func ExportConfig(db *gorm.DB, secrets bool) (*model.ConfigBundle, error) {
+	b := &model.ConfigBundle{
+		Version:    model.ConfigBundleVersion,
+		ExportedAt: time.Now(),
+	}
+	for _, dest := range []any{
+		&b.Servers, &b.ServerGroups, &b.ServerGroupServers, &b.Services, &b.AlertRules, &b.Crons,
+		&b.Notifications, &b.NotificationGroups, &b.NotificationGroupNotifications, &b.DDNSProfiles, &b.NATs,
+	} {
+		if err := db.Order("id").Find(dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	if !secrets {
+		for _, s := range b.Services {
+			s.HeartbeatToken = ""
+		}
+	}
+	return b, nil
+}
+
+// ImportConfig 在一个事务中按 ID 写入导出的配置项，已存在的同 ID 配置项会被覆盖
+// 导入分组时，分组成员以导入的配置为准
+func ImportConfig(db *gorm.DB, b *model.ConfigBundle) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range b.Servers {
+			data, err := utils.Json.Marshal(s.DDNSProfiles)
+			if err != nil {
+				return err
+			}
+			s.DDNSProfilesRaw = string(data)
+		}
+
+		var serverGroupIDs, notificationGroupIDs []uint64
+		for _, g := range b.ServerGroups {
+			serverGroupIDs = append(serverGroupIDs, g.ID)
+		}
+		for _, g := range b.NotificationGroups {
+			notificationGroupIDs = append(notificationGroupIDs, g.ID)
+		}
+		if len(serverGroupIDs) > 0 {
+			if err := tx.Unscoped().Delete(&model.ServerGroupServer{}, "server_group_id IN (?)", serverGroupIDs).Error; err != nil {
+				return err
+			}
+		}
+		if len(notificationGroupIDs) > 0 {
+			if err := tx.Unscoped().Delete(&model.NotificationGroupNotification{}, "notification_group_id IN (?)", notificationGroupIDs).Error; err != nil {
+				return err
+			}
+		}
+		for _, s := range b.Services {
+			if err := importHeartbeatToken(tx, s); err != nil {
+				return err
+			}
+		}
+		for _, m := range b.ServerGroupServers {
+			m.ID = 0
+		}
+		for _, m := range b.NotificationGroupNotifications {
+			m.ID = 0
+		}
+
+		for _, save := range []func() error{
+			func() error { return saveAll(tx, b.Servers) },
+			func() error { return saveAll(tx, b.ServerGroups) },
+			func() error { return saveAll(tx, b.Services) },
+			func() error { return saveAll(tx, b.AlertRules) },
+			func() error { return saveAll(tx, b.Crons) },
+			func() error { return saveAll(tx, b.Notifications) },
+			func() error { return saveAll(tx, b.NotificationGroups) },
+			func() error { return saveAll(tx, b.DDNSProfiles) },
+			func() error { return saveAll(tx, b.NATs) },
+		} {
+			if err := save(); err != nil {
+				return err
+			}
+		}
+		if len(b.ServerGroupServers) > 0 {
+			if err := tx.Create(b.ServerGroupServers).Error; err != nil {
+				return err
+			}
+		}
+		if len(b.NotificationGroupNotifications) > 0 {
+			if err := tx.Create(b.NotificationGroupNotifications).Error; err != nil {
+				return err
+			}
+		}
+		return model.ResetIDSequence(tx, &model.Server{}, &model.ServerGroup{}, &model.Service{}, &model.AlertRule{}, &model.Cron{},
+			&model.Notification{}, &model.NotificationGroup{}, &model.DDNSProfile{}, &model.NAT{})
+	})
+}
+
+// importHeartbeatToken 导出文件中没有心跳监控的上报密钥时，沿用同 ID 服务监控已有的密钥，否则重新生成
+func importHeartbeatToken(tx *gorm.DB, s *model.Service) error {
+	if s.Type != model.TaskTypeHeartbeat || s.HeartbeatToken != "" {
+		return nil
+	}
+	var tokens []string
+	if err := tx.Model(&model.Service{}).Where("id = ? AND type = ?", s.ID, model.TaskTypeHeartbeat).
+		Pluck("heartbeat_token", &tokens).Error; err != nil {
+		return err
+	}
+	if len(tokens) > 0 && tokens[0] != "" {
+		s.HeartbeatToken = tokens[0]
+		return nil
+	}
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+	s.HeartbeatToken = token
+	return nil
+}
+
+func saveAll[T any](tx *gorm.DB, list []*T) error {
+	for _, item := range list {
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
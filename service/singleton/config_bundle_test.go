@@ -0,0 +1,54 @@
+package singleton
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestConfigBundleHeartbeatToken(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+
+	service := model.Service{Name: "backup", Type: model.TaskTypeHeartbeat, HeartbeatToken: "heartbeat-token"}
+	if err := DB.Create(&service).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	b, err := ExportConfig(DB, false)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if len(b.Services) != 1 || b.Services[0].HeartbeatToken != "" {
+		t.Fatalf("Expected heartbeat token to be stripped, but got %+v", b.Services)
+	}
+	withSecrets, err := ExportConfig(DB, true)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if withSecrets.Services[0].HeartbeatToken != service.HeartbeatToken {
+		t.Fatalf("Expected %s, but got %s", service.HeartbeatToken, withSecrets.Services[0].HeartbeatToken)
+	}
+
+	// 导入到已有的服务监控时沿用已有的密钥，新的服务监控重新生成密钥
+	created := *b.Services[0]
+	created.ID = service.ID + 1
+	b.Services = append(b.Services, &created)
+	if err := ImportConfig(DB, b); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var services []model.Service
+	if err := DB.Order("id").Find(&services).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 services, but got %d", len(services))
+	}
+	if services[0].HeartbeatToken != service.HeartbeatToken {
+		t.Fatalf("Expected %s, but got %s", service.HeartbeatToken, services[0].HeartbeatToken)
+	}
+	if services[1].HeartbeatToken == "" || services[1].HeartbeatToken == service.HeartbeatToken {
+		t.Fatalf("Expected a new heartbeat token, but got %q", services[1].HeartbeatToken)
+	}
+}
@@ -0,0 +1,208 @@
+package singleton
+
+import (
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+var (
+	ErrInvalidEnrollmentToken = errors.New("invalid enrollment token")
+	// ErrEnrollmentNotAllowed 已存在的服务器只有在独立密钥被管理员吊销后才能使用注册令牌重新注册
+	ErrEnrollmentNotAllowed = errors.New("server already exists")
+)
+
+var (
+	// serverCredentials 缓存服务器的独立密钥，避免 Agent 每次请求都查询数据库，未设置独立密钥的服务器不在其中
+	serverCredentials     = make(map[uint64]*model.ServerCredential) // [ServerID] -> model.ServerCredential
+	serverCredentialsLock sync.RWMutex
+)
+
+// loadServerCredentials 从数据库加载全部服务器的独立密钥
+func loadServerCredentials() {
+	var creds []*model.ServerCredential
+	DB.Find(&creds)
+	serverCredentialsLock.Lock()
+	defer serverCredentialsLock.Unlock()
+	serverCredentials = make(map[uint64]*model.ServerCredential, len(creds))
+	for _, cred := range creds {
+		serverCredentials[cred.ServerID] = cred
+	}
+}
+
+// cacheServerCredentials 数据库写入成功后更新缓存
+func cacheServerCredentials(creds ...*model.ServerCredential) {
+	serverCredentialsLock.Lock()
+	defer serverCredentialsLock.Unlock()
+	for _, cred := range creds {
+		serverCredentials[cred.ServerID] = cred
+	}
+}
+
+// removeServerCredentials 删除服务器后移除缓存的独立密钥
+func removeServerCredentials(serverIDs []uint64) {
+	serverCredentialsLock.Lock()
+	defer serverCredentialsLock.Unlock()
+	for _, id := range serverIDs {
+		delete(serverCredentials, id)
+	}
+}
+
+// CreateEnrollmentToken 生成注册令牌，返回只出现一次的令牌明文
+func CreateEnrollmentToken(t *model.EnrollmentToken) (string, error) {
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	t.TokenHash = model.HashAgentSecret(token)
+	if err := DB.Create(t).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// saveServerSecret 为服务器生成新的独立密钥并写入数据库，调用方在事务提交后更新缓存
+func saveServerSecret(db *gorm.DB, serverID uint64) (*model.ServerCredential, string, error) {
+	secret, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+	cred := &model.ServerCredential{
+		ServerID:   serverID,
+		SecretHash: model.HashAgentSecret(secret),
+		CreatedAt:  time.Now(),
+	}
+	// Save 会写入全部字段，同时清除吊销状态
+	if err := db.Save(cred).Error; err != nil {
+		return nil, "", err
+	}
+	return cred, secret, nil
+}
+
+// IssueServerSecret 为服务器生成新的独立密钥，旧密钥立即失效
+func IssueServerSecret(serverID uint64) (string, error) {
+	cred, secret, err := saveServerSecret(DB, serverID)
+	if err != nil {
+		return "", err
+	}
+	cacheServerCredentials(cred)
+	return secret, nil
+}
+
+// ResetServerSecret 为服务器重新生成独立密钥，并断开使用旧凭据建立的连接
+func ResetServerSecret(serverID uint64) (string, error) {
+	secret, err := IssueServerSecret(serverID)
+	if err != nil {
+		return "", err
+	}
+	CloseAgentStreams([]uint64{serverID})
+	return secret, nil
+}
+
+// RevokeServerSecrets 吊销服务器的独立密钥并断开已建立的连接，仍在使用 AgentSecretKey 的服务器也将无法连接
+func RevokeServerSecrets(serverIDs []uint64) error {
+	now := time.Now()
+	creds := make([]*model.ServerCredential, 0, len(serverIDs))
+	if err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range serverIDs {
+			cred := &model.ServerCredential{ServerID: id, CreatedAt: now, RevokedAt: &now}
+			if err := tx.Save(cred).Error; err != nil {
+				return err
+			}
+			creds = append(creds, cred)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	cacheServerCredentials(creds...)
+	CloseAgentStreams(serverIDs)
+	return nil
+}
+
+// GetServerCredential 从缓存中查询服务器的独立密钥，未设置时返回 nil，返回值不可修改
+func GetServerCredential(serverID uint64) *model.ServerCredential {
+	serverCredentialsLock.RLock()
+	defer serverCredentialsLock.RUnlock()
+	return serverCredentials[serverID]
+}
+
+// EnrollServer 使用注册令牌注册服务器并生成独立密钥，server 为空时创建新服务器。
+// 已存在的服务器只有独立密钥被吊销后才能重新注册，否则任意令牌都能接管该服务器。
+// 令牌在同一事务中被标记为已使用，并发注册时只有一个 Agent 能成功
+func EnrollServer(token, uuid string, server *model.Server) (*model.Server, string, error) {
+	if server != nil {
+		cred := GetServerCredential(server.ID)
+		if cred == nil || !cred.Revoked() {
+			return nil, "", ErrEnrollmentNotAllowed
+		}
+	}
+
+	var t model.EnrollmentToken
+	result := DB.Where("token_hash = ?", model.HashAgentSecret(token)).Limit(1).Find(&t)
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	now := time.Now()
+	if result.RowsAffected == 0 || !t.Usable(now) {
+		return nil, "", ErrInvalidEnrollmentToken
+	}
+
+	var s model.Server
+	if server != nil {
+		s = *server
+	} else {
+		s = model.Server{UUID: uuid, Name: petname.Generate(2, "-")}
+	}
+	if len(t.Labels) > 0 {
+		labels := make(map[string]string, len(s.Labels)+len(t.Labels))
+		maps.Copy(labels, s.Labels)
+		maps.Copy(labels, t.Labels)
+		s.Labels = labels
+	}
+
+	var (
+		cred   *model.ServerCredential
+		secret string
+	)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&s).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.EnrollmentToken{}).Where("id = ? AND used_at IS NULL", t.ID).
+			Updates(map[string]any{"used_at": now, "server_id": s.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidEnrollmentToken
+		}
+		if t.ServerGroupID != 0 {
+			var count int64
+			if err := tx.Model(&model.ServerGroup{}).Where("id = ?", t.ServerGroupID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				sgs := model.ServerGroupServer{ServerGroupId: t.ServerGroupID, ServerId: s.ID}
+				if err := tx.Where(&sgs).FirstOrCreate(&sgs).Error; err != nil {
+					return err
+				}
+			}
+		}
+		var err error
+		cred, secret, err = saveServerSecret(tx, s.ID)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	cacheServerCredentials(cred)
+	return &s, secret, nil
+}
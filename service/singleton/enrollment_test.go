@@ -0,0 +1,171 @@
+package singleton
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestEnrollServerTakeover(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+	loadServerCredentials()
+
+	server := model.Server{UUID: "7f1d6a0e-3f5b-4c1e-9c53-5d7c2a8e4b10", Name: "existing"}
+	if err := DB.Create(&server).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var token model.EnrollmentToken
+	plain, err := CreateEnrollmentToken(&token)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	// 未设置独立密钥的已有服务器不允许使用注册令牌接管
+	if _, _, err := EnrollServer(plain, server.UUID, &server); !errors.Is(err, ErrEnrollmentNotAllowed) {
+		t.Fatalf("Expected ErrEnrollmentNotAllowed, but got %v", err)
+	}
+	if cred := GetServerCredential(server.ID); cred != nil {
+		t.Fatalf("Expected no credential, but got %v", cred)
+	}
+	if err := DB.First(&token, token.ID).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if token.UsedAt != nil {
+		t.Fatalf("Expected token to remain unused, but got used at %v", token.UsedAt)
+	}
+
+	// 独立密钥有效时同样不允许
+	if _, err := IssueServerSecret(server.ID); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if _, _, err := EnrollServer(plain, server.UUID, &server); !errors.Is(err, ErrEnrollmentNotAllowed) {
+		t.Fatalf("Expected ErrEnrollmentNotAllowed, but got %v", err)
+	}
+
+	// 管理员吊销后可以重新注册
+	if err := RevokeServerSecrets([]uint64{server.ID}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	s, secret, err := EnrollServer(plain, server.UUID, &server)
+	if err != nil {
+		t.Fatalf("Expected enrollment after revoke, but got %v", err)
+	}
+	if s.ID != server.ID || secret == "" {
+		t.Fatalf("Expected server %d with secret, but got %d %q", server.ID, s.ID, secret)
+	}
+
+	// 新服务器可以注册
+	var token2 model.EnrollmentToken
+	plain2, err := CreateEnrollmentToken(&token2)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	s, _, err = EnrollServer(plain2, "0b8e6a52-8c1f-4d2b-a7a4-1e9f3c6d5b27", nil)
+	if err != nil {
+		t.Fatalf("Expected new server enrollment, but got %v", err)
+	}
+	if s.ID == server.ID {
+		t.Fatalf("Expected a new server, but got %d", s.ID)
+	}
+}
+
+func TestRevokeServerSecretsClosesStreams(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+	loadServerCredentials()
+
+	servers := []model.Server{{UUID: "3c9d2f6e-1a7b-4e8c-b5d0-6f2a9e4c7b13"}, {UUID: "a6e4c1b8-5d2f-4a9e-8c7b-0d3f6e1a2b45"}}
+	if err := DB.Create(&servers).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	loadServers()
+
+	assertClosed := func(ctx context.Context, closed bool) {
+		t.Helper()
+		select {
+		case <-ctx.Done():
+			if !closed {
+				t.Fatalf("Expected stream to stay open, but got closed")
+			}
+			if !errors.Is(context.Cause(ctx), ErrAgentCredentialRevoked) {
+				t.Fatalf("Expected ErrAgentCredentialRevoked, but got %v", context.Cause(ctx))
+			}
+		case <-time.After(100 * time.Millisecond):
+			if closed {
+				t.Fatalf("Expected stream to be closed, but got open")
+			}
+		}
+	}
+
+	revoked, untrack1 := TrackAgentStream(context.Background(), servers[0].ID)
+	defer untrack1()
+	other, untrack2 := TrackAgentStream(context.Background(), servers[1].ID)
+	defer untrack2()
+
+	if err := RevokeServerSecrets([]uint64{servers[0].ID}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	assertClosed(revoked, true)
+	assertClosed(other, false)
+
+	if _, err := ResetServerSecret(servers[1].ID); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	assertClosed(other, true)
+
+	// 流结束后取消登记
+	untrack1()
+	untrack2()
+	if len(agentStreams) != 0 {
+		t.Fatalf("Expected no tracked streams, but got %d", len(agentStreams))
+	}
+}
+
+func TestServerCredentialCache(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+
+	server := model.Server{UUID: "5e2b7c4a-9d1f-4b3e-8a6c-2f7d1e9b4c38"}
+	if err := DB.Create(&server).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	loadServers()
+	loadServerCredentials()
+
+	secret, err := IssueServerSecret(server.ID)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	// 校验只读取缓存，不查询数据库
+	if err := DB.Delete(&model.ServerCredential{}, "server_id = ?", server.ID).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if cred := GetServerCredential(server.ID); cred == nil || !cred.Verify(secret) {
+		t.Fatalf("Expected cached credential to verify, but got %v", cred)
+	}
+
+	reset, err := ResetServerSecret(server.ID)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if cred := GetServerCredential(server.ID); cred == nil || cred.Verify(secret) || !cred.Verify(reset) {
+		t.Fatalf("Expected only the reset secret to verify, but got %v", cred)
+	}
+
+	if err := RevokeServerSecrets([]uint64{server.ID}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if cred := GetServerCredential(server.ID); cred == nil || !cred.Revoked() {
+		t.Fatalf("Expected revoked credential, but got %v", cred)
+	}
+
+	OnServerDelete([]uint64{server.ID})
+	if cred := GetServerCredential(server.ID); cred != nil {
+		t.Fatalf("Expected no credential after delete, but got %v", cred)
+	}
+}
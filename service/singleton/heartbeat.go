@@ -0,0 +1,84 @@
+package singleton
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+var ErrHeartbeatTokenInvalid = errors.New("invalid heartbeat token")
+
+// serviceCronJob 返回服务监控的定时任务，主动监控交给任务调度管道，心跳监控检查是否超时未上报
+func (ss *ServiceSentinel) serviceCronJob(m model.Service) func() {
+	if !m.IsAgentTask() {
+		return func() {
+			ss.checkHeartbeat(m)
+		}
+	}
+	return func() {
+		ss.dispatchBus <- m
+	}
+}
+
+// Heartbeat 处理被监控方通过心跳地址上报的状态
+func (ss *ServiceSentinel) Heartbeat(token string, successful bool, message string) error {
+	if token == "" {
+		return ErrHeartbeatTokenInvalid
+	}
+
+	var serviceID uint64
+	ss.ServicesLock.RLock()
+	for _, s := range ss.Services {
+		if s.Type == model.TaskTypeHeartbeat && s.HeartbeatToken != "" &&
+			subtle.ConstantTimeCompare([]byte(s.HeartbeatToken), []byte(token)) == 1 {
+			serviceID = s.ID
+			break
+		}
+	}
+	ss.ServicesLock.RUnlock()
+	if serviceID == 0 {
+		return ErrHeartbeatTokenInvalid
+	}
+
+	ss.heartbeatLock.Lock()
+	ss.heartbeatLastPing[serviceID] = time.Now()
+	ss.heartbeatLock.Unlock()
+
+	ss.Dispatch(ReportData{
+		Data: &pb.TaskResult{
+			Id:         serviceID,
+			Type:       model.TaskTypeHeartbeat,
+			Successful: successful,
+			Data:       message,
+		},
+	})
+	return nil
+}
+
+// checkHeartbeat 超过 Duration + 宽限时间仍未收到心跳时记录一次离线
+func (ss *ServiceSentinel) checkHeartbeat(m model.Service) {
+	ss.heartbeatLock.Lock()
+	lastPing, ok := ss.heartbeatLastPing[m.ID]
+	if !ok {
+		// 面板重启或新建监控后从当前时间开始计时
+		ss.heartbeatLastPing[m.ID] = time.Now()
+		ss.heartbeatLock.Unlock()
+		return
+	}
+	ss.heartbeatLock.Unlock()
+
+	if time.Since(lastPing) <= m.HeartbeatTimeout() {
+		return
+	}
+
+	ss.Dispatch(ReportData{
+		Data: &pb.TaskResult{
+			Id:   m.ID,
+			Type: model.TaskTypeHeartbeat,
+			Data: Localizer.Tf("No heartbeat received since %s", lastPing.In(Loc).Format(time.DateTime)),
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package singleton
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestServiceSentinelHeartbeat(t *testing.T) {
+	ss := &ServiceSentinel{
+		serviceReportChannel: make(chan ReportData, 1),
+		Services: map[uint64]*model.Service{
+			1: {Common: model.Common{ID: 1}, Type: model.TaskTypeHeartbeat, HeartbeatToken: "heartbeat-token"},
+			2: {Common: model.Common{ID: 2}, Type: model.TaskTypeHTTPGet},
+		},
+		heartbeatLastPing: make(map[uint64]time.Time),
+	}
+
+	for _, token := range []string{"", "invalid-token"} {
+		if err := ss.Heartbeat(token, true, ""); !errors.Is(err, ErrHeartbeatTokenInvalid) {
+			t.Fatalf("Expected ErrHeartbeatTokenInvalid for %q, but got %v", token, err)
+		}
+	}
+
+	cases := []struct {
+		successful bool
+		message    string
+	}{
+		{true, ""},
+		{false, "disk full"},
+	}
+	for _, c := range cases {
+		if err := ss.Heartbeat("heartbeat-token", c.successful, c.message); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		r := <-ss.serviceReportChannel
+		if r.Data.GetId() != 1 || r.Data.GetType() != model.TaskTypeHeartbeat {
+			t.Fatalf("Expected heartbeat report of service 1, but got %+v", r.Data)
+		}
+		if r.Data.GetSuccessful() != c.successful || r.Data.GetData() != c.message {
+			t.Fatalf("Expected %v %q, but got %v %q", c.successful, c.message, r.Data.GetSuccessful(), r.Data.GetData())
+		}
+	}
+	if _, ok := ss.heartbeatLastPing[1]; !ok {
+		t.Fatalf("Expected last ping of service 1 to be recorded")
+	}
+}
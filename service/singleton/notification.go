@@ -317,6 +317,16 @@ func (_NotificationMuteLabel) ServiceLatencyMax(serviceId uint64) *string {
 	return &label
 }
 
+func (_NotificationMuteLabel) ServiceLoss(serviceId uint64) *string {
+	label := fmt.Sprintf("bf::sls-%d", serviceId)
+	return &label
+}
+
+func (_NotificationMuteLabel) ServiceJitter(serviceId uint64) *string {
+	label := fmt.Sprintf("bf::sjt-%d", serviceId)
+	return &label
+}
+
 func (_NotificationMuteLabel) ServiceStateChanged(serviceId uint64) *string {
 	label := fmt.Sprintf("bf::ssc-%d", serviceId)
 	return &label
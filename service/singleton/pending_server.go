@@ -0,0 +1,160 @@
+package singleton
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+)
+
+const (
+	MaxPendingServers            = 1000            // 审核队列中等待审核的 Agent 数量上限
+	pendingServerRefreshInterval = 1 * time.Minute // 同一 Agent 的审核记录在该时长内最多更新一次
+)
+
+var (
+	ErrServerRejected         = errors.New("server rejected")
+	ErrPendingServerQueueFull = errors.New("pending server queue is full")
+)
+
+type pendingServerCacheEntry struct {
+	rejected  bool
+	checkedAt time.Time
+}
+
+var (
+	pendingServerCache     = make(map[string]pendingServerCacheEntry)
+	pendingServerCacheLock sync.Mutex
+)
+
+// QueuePendingServer 将新 Agent 加入审核队列并记录连接 IP，已拒绝的 Agent 返回 ErrServerRejected。
+// Agent 每次连接都会调用，审核记录按 UUID 缓存，队列已满时不再接受新的 Agent
+func QueuePendingServer(uuid, ip string) error {
+	now := time.Now()
+	pendingServerCacheLock.Lock()
+	entry, ok := pendingServerCache[uuid]
+	pendingServerCacheLock.Unlock()
+	if ok && now.Sub(entry.checkedAt) < pendingServerRefreshInterval {
+		if entry.rejected {
+			return ErrServerRejected
+		}
+		return nil
+	}
+
+	var p model.PendingServer
+	result := DB.Where("uuid = ?", uuid).Limit(1).Find(&p)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := DB.Model(&model.PendingServer{}).Where("rejected = ?", false).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxPendingServers {
+			return ErrPendingServerQueueFull
+		}
+	}
+
+	if !p.Rejected {
+		// 并发连接时由唯一索引去重，不覆盖拒绝状态与主机信息
+		if err := DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "uuid"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ip", "last_seen", "updated_at"}),
+		}).Create(&model.PendingServer{UUID: uuid, IP: ip, LastSeen: now}).Error; err != nil {
+			return err
+		}
+	}
+
+	pendingServerCacheLock.Lock()
+	for k, e := range pendingServerCache {
+		if now.Sub(e.checkedAt) >= pendingServerRefreshInterval {
+			delete(pendingServerCache, k)
+		}
+	}
+	pendingServerCache[uuid] = pendingServerCacheEntry{rejected: p.Rejected, checkedAt: now}
+	pendingServerCacheLock.Unlock()
+
+	if p.Rejected {
+		return ErrServerRejected
+	}
+	return nil
+}
+
+// forgetPendingServers 审核状态变化后清空缓存，使 Agent 下次连接时重新读取审核记录
+func forgetPendingServers() {
+	pendingServerCacheLock.Lock()
+	clear(pendingServerCache)
+	pendingServerCacheLock.Unlock()
+}
+
+// UpdatePendingServerHost 记录待审核 Agent 上报的主机信息
+func UpdatePendingServerHost(uuid string, host *model.Host) error {
+	data, err := utils.Json.Marshal(host)
+	if err != nil {
+		return err
+	}
+	return DB.Model(&model.PendingServer{}).Where("uuid = ? AND rejected = ?", uuid, false).
+		Update("host_raw", string(data)).Error
+}
+
+// ApprovePendingServers 通过审核，为 Agent 创建服务器
+func ApprovePendingServers(ids []uint64) error {
+	var pending []*model.PendingServer
+	if err := DB.Find(&pending, "id in (?)", ids).Error; err != nil {
+		return err
+	}
+
+	servers := make([]*model.Server, 0, len(pending))
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range pending {
+			s := &model.Server{UUID: p.UUID, Name: petname.Generate(2, "-")}
+			if err := tx.Create(s).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(p).Error; err != nil {
+				return err
+			}
+			servers = append(servers, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	forgetPendingServers()
+
+	for i, s := range servers {
+		AddServer(s)
+		if pending[i].Host != nil {
+			ServerLock.Lock()
+			s.Host = pending[i].Host
+			ServerLock.Unlock()
+		}
+	}
+	return nil
+}
+
+// RejectPendingServers 拒绝 Agent，删除审核记录后 Agent 可以重新进入审核队列
+func RejectPendingServers(ids []uint64) error {
+	if err := DB.Model(&model.PendingServer{}).Where("id in (?)", ids).Update("rejected", true).Error; err != nil {
+		return err
+	}
+	forgetPendingServers()
+	return nil
+}
+
+// DeletePendingServers 删除审核记录，Agent 下次连接时重新进入审核队列
+func DeletePendingServers(ids []uint64) error {
+	if err := DB.Unscoped().Delete(&model.PendingServer{}, "id in (?)", ids).Error; err != nil {
+		return err
+	}
+	forgetPendingServers()
+	return nil
+}
@@ -0,0 +1,71 @@
+package singleton
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestQueuePendingServer(t *testing.T) {
+	Conf = &model.Config{}
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+	forgetPendingServers()
+
+	const uuid = "9d4b2e71-6c3a-4f8e-b1d5-2a7c9e0f4b36"
+	for i := 0; i < 3; i++ {
+		if err := QueuePendingServer(uuid, "10.0.0.1"); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+	}
+	var pending []model.PendingServer
+	if err := DB.Find(&pending).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending server, but got %d", len(pending))
+	}
+
+	// 刷新间隔内重复连接不访问数据库
+	if err := DB.Unscoped().Where("1 = 1").Delete(&model.PendingServer{}).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := QueuePendingServer(uuid, "10.0.0.1"); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var count int64
+	DB.Model(&model.PendingServer{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("Expected cached result without writing, but got %d rows", count)
+	}
+
+	// 拒绝后立即生效
+	forgetPendingServers()
+	if err := QueuePendingServer(uuid, "10.0.0.1"); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var p model.PendingServer
+	if err := DB.Where("uuid = ?", uuid).First(&p).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := RejectPendingServers([]uint64{p.ID}); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := QueuePendingServer(uuid, "10.0.0.1"); !errors.Is(err, ErrServerRejected) {
+		t.Fatalf("Expected ErrServerRejected, but got %v", err)
+	}
+
+	// 队列已满时不接受新的 Agent
+	fill := make([]model.PendingServer, 0, MaxPendingServers)
+	for i := 0; i < MaxPendingServers; i++ {
+		fill = append(fill, model.PendingServer{UUID: fmt.Sprintf("fill-%d", i)})
+	}
+	if err := DB.CreateInBatches(&fill, 100).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := QueuePendingServer("2f6a8c14-7e3b-4d9a-a5c0-1b8e6d3f7a92", "10.0.0.2"); !errors.Is(err, ErrPendingServerQueueFull) {
+		t.Fatalf("Expected ErrPendingServerQueueFull, but got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package singleton
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// TopProcessesTimeout 等待 Agent 上报进程快照的超时时间
+const TopProcessesTimeout = 10 * time.Second
+
+type topProcessesRequest struct {
+	serverID uint64
+	result   chan *pb.TaskResult
+}
+
+var (
+	topProcessesRequestID   atomic.Uint64
+	topProcessesRequests    = make(map[uint64]*topProcessesRequest)
+	topProcessesRequestLock sync.Mutex
+)
+
+// RequestTopProcesses 向服务器下发进程快照任务并等待 Agent 上报结果
+func RequestTopProcesses(serverID uint64, limit int, timeout time.Duration) (*model.TopProcesses, error) {
+	ServerLock.RLock()
+	var stream pb.NezhaService_RequestTaskServer
+	if server, ok := ServerList[serverID]; ok {
+		stream = server.TaskStream
+	}
+	ServerLock.RUnlock()
+	if stream == nil {
+		return nil, Localizer.ErrorT("server not found or not connected")
+	}
+
+	if limit <= 0 {
+		limit = model.DefaultTopProcessesLimit
+	}
+	if limit > model.MaxTopProcessesLimit {
+		limit = model.MaxTopProcessesLimit
+	}
+	data, err := utils.Json.Marshal(&model.TaskTopProcesses{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	id := topProcessesRequestID.Add(1)
+	req := &topProcessesRequest{serverID: serverID, result: make(chan *pb.TaskResult, 1)}
+	topProcessesRequestLock.Lock()
+	topProcessesRequests[id] = req
+	topProcessesRequestLock.Unlock()
+	defer func() {
+		topProcessesRequestLock.Lock()
+		delete(topProcessesRequests, id)
+		topProcessesRequestLock.Unlock()
+	}()
+
+	if err := stream.Send(&pb.Task{
+		Id:   id,
+		Type: model.TaskTypeTopProcesses,
+		Data: string(data),
+	}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-req.result:
+		if !r.GetSuccessful() {
+			return nil, errors.New(r.GetData())
+		}
+		var tp model.TopProcesses
+		if err := utils.Json.Unmarshal([]byte(r.GetData()), &tp); err != nil {
+			return nil, err
+		}
+		tp.Truncate(limit)
+		return &tp, nil
+	case <-time.After(timeout):
+		return nil, Localizer.ErrorT("timeout waiting for agent response")
+	}
+}
+
+// OnTopProcessesResult 将 Agent 上报的进程快照交给等待中的请求
+func OnTopProcessesResult(serverID uint64, r *pb.TaskResult) {
+	topProcessesRequestLock.Lock()
+	req, ok := topProcessesRequests[r.GetId()]
+	topProcessesRequestLock.Unlock()
+	if !ok || req.serverID != serverID {
+		log.Printf("NEZHA>> 进程快照请求不存在或已超时，id: %d, serverID: %d\n", r.GetId(), serverID)
+		return
+	}
+	select {
+	case req.result <- r:
+	default:
+	}
+}
+
+// createAlertEvent 记录报警事件，返回事件 ID，失败时返回 0。
+// 需在检查报警状态时同步调用，保证事件在对应的恢复之前写入
+func createAlertEvent(alertID, serverID uint64, message string) uint64 {
+	event := model.AlertEvent{
+		AlertRuleID: alertID,
+		ServerID:    serverID,
+		Message:     message,
+	}
+	if err := DB.Create(&event).Error; err != nil {
+		log.Println("NEZHA>> 报警事件持久化失败：", err)
+		return 0
+	}
+	return event.ID
+}
+
+// notifyAlertIncident 资源占用类报警附带进程快照后发送通知，并将快照写入报警事件
+func notifyAlertIncident(alertID, eventID, notificationGroupID uint64, withProcesses bool, message string, server *model.Server) {
+	if withProcesses {
+		if tp, err := RequestTopProcesses(server.ID, model.DefaultTopProcessesLimit, TopProcessesTimeout); err != nil {
+			log.Printf("NEZHA>> 报警进程快照采集失败：%v, alertID: %d, serverID: %d\n", err, alertID, server.ID)
+		} else {
+			message += "\n" + tp.Summary(model.TopProcessesNotifyLimit)
+			// 采集期间事件可能已恢复，只更新快照相关字段
+			if data, err := utils.Json.Marshal(tp); err == nil && eventID > 0 {
+				DB.Model(&model.AlertEvent{}).Where("id = ?", eventID).Updates(map[string]any{
+					"message":       message,
+					"processes_raw": string(data),
+				})
+			}
+		}
+	}
+	SendNotification(notificationGroupID, message, NotificationMuteLabel.ServerIncident(server.ID, alertID), server)
+}
+
+// resolveAlertEvents 标记报警规则在服务器上未恢复的报警事件为已恢复
+func resolveAlertEvents(alertID, serverID uint64) {
+	if err := DB.Model(&model.AlertEvent{}).Where("alert_rule_id = ? AND server_id = ? AND resolved_at IS NULL", alertID, serverID).
+		Update("resolved_at", time.Now()).Error; err != nil {
+		log.Println("NEZHA>> 报警事件更新失败：", err)
+	}
+}
@@ -0,0 +1,160 @@
+package singleton
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// retentionRule 一条数据清理规则
+type retentionRule struct {
+	class string
+	model any
+	days  int
+	query string
+	args  []any
+}
+
+// retentionRules 根据数据保留策略生成全部清理规则
+func retentionRules() []retentionRule {
+	policy := Conf.Retention
+	now := time.Now()
+	before := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+
+	rules := []retentionRule{
+		// 清理已被删除的服务器与服务的相关记录
+		{class: model.RetentionClassOrphan, model: &model.ServiceHistory{}, query: "service_id NOT IN (SELECT id FROM services)"},
+		{class: model.RetentionClassOrphan, model: &model.Transfer{}, query: "server_id NOT IN (SELECT id FROM servers)"},
+		{class: model.RetentionClassOrphan, model: &model.Traceroute{}, query: "server_id NOT IN (SELECT id FROM servers)"},
+		{class: model.RetentionClassOrphan, model: &model.StatusPageNotice{}, query: "status_page_id NOT IN (SELECT id FROM status_pages)"},
+		{class: model.RetentionClassOrphan, model: &model.ServiceDailyStats{}, query: "service_id NOT IN (SELECT id FROM services)"},
+		{class: model.RetentionClassOrphan, model: &model.ServiceIncident{}, query: "service_id NOT IN (SELECT id FROM services)"},
+		// server_id = 0 的数据会用于/service页面的可用性展示
+		{class: model.RetentionClassServiceHistory, model: &model.ServiceHistory{}, days: policy.ServiceHistoryDays,
+			query: "server_id = 0 AND created_at < ?", args: []any{before(policy.ServiceHistoryDays)}},
+		// 由于网络监控记录的数据较多，并且前端仅使用了 1 天的数据，考虑到 sqlite 数据量问题，默认仅保留一天数据
+		{class: model.RetentionClassPingHistory, model: &model.ServiceHistory{}, days: policy.PingHistoryDays,
+			query: "server_id != 0 AND created_at < ?", args: []any{before(policy.PingHistoryDays)}},
+		{class: model.RetentionClassTraceroute, model: &model.Traceroute{}, days: policy.TracerouteDays,
+			query: "created_at < ?", args: []any{before(policy.TracerouteDays)}},
+	}
+
+	// 每日统计按各服务监控的保留天数清理，故障记录按最长的保留天数清理
+	// 只查询需要的列，使用 Scan 避免 Service.AfterFind 解析未查询的 JSON 字段
+	var services []struct {
+		ID               uint64
+		SLARetentionDays uint64
+	}
+	DB.Model(&model.Service{}).Select("id", "sla_retention_days").Scan(&services)
+	maxSLADays := policy.SLADays
+	for _, s := range services {
+		days := policy.SLADays
+		if s.SLARetentionDays > 0 {
+			days = int(s.SLARetentionDays)
+		}
+		maxSLADays = max(maxSLADays, days)
+		rules = append(rules, retentionRule{class: model.RetentionClassSLA, model: &model.ServiceDailyStats{}, days: days,
+			query: "service_id = ? AND date < ?", args: []any{s.ID, before(days)}})
+	}
+	rules = append(rules, retentionRule{class: model.RetentionClassSLA, model: &model.ServiceIncident{}, days: maxSLADays,
+		query: "ended_at < ?", args: []any{before(maxSLADays)}})
+
+	return append(rules, transferRetentionRules(policy.TransferDays, now)...)
+}
+
+// transferRetentionRules 流量记录只清理早于保留天数且早于流量报警规则统计周期起点的数据，
+// 未配置保留天数时只保留统计周期内的数据
+func transferRetentionRules(days int, now time.Time) []retentionRule {
+	// 保留起点为零值时表示不需要保留
+	var configKeep time.Time
+	if days > 0 {
+		configKeep = now.AddDate(0, 0, -days).UTC()
+	}
+	// earlier 返回两个保留起点中更早的一个
+	earlier := func(a, b time.Time) time.Time {
+		if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+			return b
+		}
+		return a
+	}
+
+	allServerKeep := configKeep
+	specialServerKeep := make(map[uint64]time.Time)
+	var alerts []model.AlertRule
+	DB.Find(&alerts)
+	for _, alert := range alerts {
+		for _, rule := range alert.Rules {
+			// 是不是流量记录规则
+			if !rule.IsTransferDurationRule() {
+				continue
+			}
+			dataCouldRemoveBefore := rule.GetTransferDurationStart().UTC()
+			// 判断规则影响的机器范围
+			if rule.Cover == model.RuleCoverAll {
+				// 更新全局可以清理的数据点
+				allServerKeep = earlier(allServerKeep, dataCouldRemoveBefore)
+			} else {
+				// 更新特定机器可以清理数据点
+				for id := range rule.Ignore {
+					specialServerKeep[id] = earlier(specialServerKeep[id], dataCouldRemoveBefore)
+				}
+			}
+		}
+	}
+
+	var rules []retentionRule
+	specialServerIDs := make([]uint64, 0, len(specialServerKeep))
+	for id, couldRemove := range specialServerKeep {
+		specialServerIDs = append(specialServerIDs, id)
+		rules = append(rules, retentionRule{class: model.RetentionClassTransfer, model: &model.Transfer{}, days: days,
+			query: "server_id = ? AND " + model.TimeCondition(DB, "created_at", "<"), args: []any{id, earlier(couldRemove, allServerKeep)}})
+	}
+
+	var conds []string
+	var args []any
+	if !allServerKeep.IsZero() {
+		conds, args = append(conds, model.TimeCondition(DB, "created_at", "<")), append(args, allServerKeep)
+	}
+	if len(specialServerIDs) > 0 {
+		conds, args = append(conds, "server_id NOT IN (?)"), append(args, specialServerIDs)
+	}
+	if len(conds) == 0 {
+		conds = append(conds, "1 = 1")
+	}
+	return append(rules, retentionRule{class: model.RetentionClassTransfer, model: &model.Transfer{}, days: days,
+		query: strings.Join(conds, " AND "), args: args})
+}
+
+// CleanServiceHistory 按数据保留策略清理无效或过时的 监控记录 和 流量记录
+func CleanServiceHistory() {
+	for _, rule := range retentionRules() {
+		if err := DB.Unscoped().Where(rule.query, rule.args...).Delete(rule.model).Error; err != nil {
+			log.Printf("NEZHA>> 清理%s数据失败：%v", rule.class, err)
+		}
+	}
+}
+
+// DryRunRetention 统计按当前数据保留策略各规则将要删除的记录数
+func DryRunRetention() ([]model.RetentionDryRunItem, error) {
+	var items []model.RetentionDryRunItem
+	index := make(map[[2]string]int)
+	for _, rule := range retentionRules() {
+		var count int64
+		stmt := DB.Model(rule.model).Where(rule.query, rule.args...)
+		if err := stmt.Count(&count).Error; err != nil {
+			return nil, err
+		}
+		key := [2]string{rule.class, stmt.Statement.Table}
+		if i, ok := index[key]; ok {
+			items[i].Rows += count
+			continue
+		}
+		index[key] = len(items)
+		items = append(items, model.RetentionDryRunItem{Class: rule.class, Table: key[1], Days: rule.days, Rows: count})
+	}
+	return items, nil
+}
@@ -0,0 +1,104 @@
+package singleton
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+func TestRetentionRules(t *testing.T) {
+	Conf = &model.Config{}
+	Conf.Retention.FillDefaults()
+	Loc = time.UTC
+	InitDBFromPath(filepath.Join(t.TempDir(), "sqlite.db"))
+
+	now := time.Now()
+	create := func(v any) {
+		t.Helper()
+		if err := DB.Create(v).Error; err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+	}
+
+	servers := []model.Server{{Name: "a", UUID: "4c7e2a91-8b3d-4f6a-9e1c-5d2b7a0f3e68"}, {Name: "b", UUID: "e1a9d6f3-2c5b-4e8a-b7d4-0f3c6a9e2b51"}}
+	create(&servers)
+	services := []model.Service{{Name: "default"}, {Name: "short", SLARetentionDays: 7}}
+	create(&services)
+
+	create(&[]model.ServiceHistory{
+		{ServiceID: services[0].ID, CreatedAt: now.AddDate(0, 0, -40)},
+		{ServiceID: services[0].ID, CreatedAt: now.Add(-time.Hour)},
+		{ServiceID: services[0].ID, ServerID: servers[0].ID, CreatedAt: now.AddDate(0, 0, -2)},
+		{ServiceID: services[0].ID, ServerID: servers[0].ID, CreatedAt: now.Add(-time.Hour)},
+		{ServiceID: 999, CreatedAt: now},
+	})
+	create(&[]model.ServiceDailyStats{
+		{ServiceID: services[0].ID, Date: now.AddDate(0, 0, -30)},
+		{ServiceID: services[1].ID, Date: now.AddDate(0, 0, -30)},
+		{ServiceID: services[1].ID, Date: now.AddDate(0, 0, -1)},
+	})
+	endedAt := now.AddDate(0, 0, -800)
+	create(&model.ServiceIncident{ServiceID: services[0].ID, StartedAt: endedAt, EndedAt: &endedAt})
+	traceroute := model.Traceroute{ServerID: servers[0].ID}
+	traceroute.CreatedAt = now.AddDate(0, 0, -10)
+	create(&traceroute)
+
+	var transfers []model.Transfer
+	for _, server := range servers {
+		for _, createdAt := range []time.Time{now.AddDate(0, 0, -40), now.Add(-time.Hour)} {
+			transfer := model.Transfer{ServerID: server.ID}
+			transfer.CreatedAt = createdAt
+			transfers = append(transfers, transfer)
+		}
+	}
+	create(&transfers)
+	// 服务器 b 的流量报警规则统计周期从两小时前开始
+	cycleStart := now.Add(-2 * time.Hour)
+	create(&model.AlertRule{Name: "transfer", Rules: []*model.Rule{{Type: "transfer_all_cycle", Cover: model.RuleCoverIgnoreAll,
+		Ignore: map[uint64]bool{servers[1].ID: true}, CycleInterval: 1, CycleUnit: "day", CycleStart: &cycleStart}}})
+
+	// 服务监控单独配置的每日统计保留天数
+	for _, rule := range retentionRules() {
+		if rule.class == model.RetentionClassSLA && len(rule.args) == 2 && rule.args[0] == services[1].ID && rule.days != 7 {
+			t.Fatalf("Expected 7 days for service %d, but got %d", services[1].ID, rule.days)
+		}
+	}
+
+	expected := map[string]int64{
+		model.RetentionClassServiceHistory: 1,
+		model.RetentionClassPingHistory:    1,
+		model.RetentionClassOrphan:         1,
+		model.RetentionClassSLA:            2,
+		model.RetentionClassTraceroute:     1,
+		// 未配置保留天数时只保留统计周期内的流量记录
+		model.RetentionClassTransfer: 3,
+	}
+	for class, rows := range expected {
+		if got := dryRunRows(t, class); got != rows {
+			t.Fatalf("Expected %d %s rows, but got %d", rows, class, got)
+		}
+	}
+
+	// 配置保留天数后同时保留天数内的流量记录
+	Conf.Retention.TransferDays = 30
+	if rows := dryRunRows(t, model.RetentionClassTransfer); rows != 2 {
+		t.Fatalf("Expected 2 transfer rows, but got %d", rows)
+	}
+
+	Conf.Retention.TransferDays = 0
+	CleanServiceHistory()
+	var remaining []model.Transfer
+	if err := DB.Find(&remaining).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if len(remaining) != 1 || remaining[0].ServerID != servers[1].ID {
+		t.Fatalf("Expected only the cycle transfer of server %d, but got %+v", servers[1].ID, remaining)
+	}
+	for class := range expected {
+		if rows := dryRunRows(t, class); rows != 0 {
+			t.Fatalf("Expected no %s rows after cleaning, but got %d", class, rows)
+		}
+	}
+}
@@ -70,7 +70,20 @@ func ReSortServer() {
 	})
 }
 
+// AddServer 将新注册的服务器加入服务器列表
+func AddServer(s *model.Server) {
+	s.Host = &model.Host{}
+	s.State = &model.HostState{}
+	s.GeoIP = &model.GeoIP{}
+	ServerLock.Lock()
+	ServerList[s.ID] = s
+	ServerUUIDToID[s.UUID] = s.ID
+	ServerLock.Unlock()
+	ReSortServer()
+}
+
 func OnServerDelete(sid []uint64) {
+	removeServerCredentials(sid)
 	ServerLock.Lock()
 	defer ServerLock.Unlock()
 	for _, id := range sid {
@@ -11,11 +11,13 @@ import (
 
 	"github.com/jinzhu/copier"
 	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
 	pb "github.com/nezhahq/nezha/proto"
 )
 
 const (
-	_CurrentStatusSize = 30 // 统计 15 分钟内的数据为当前状态
+	_CurrentStatusSize = 30  // 统计 15 分钟内的数据为当前状态
+	_LatencySampleSize = 120 // 延迟矩阵统计每个服务器最近的样本数
 )
 
 var ServiceSentinelShared *ServiceSentinel
@@ -46,12 +48,18 @@ func NewServiceSentinel(serviceSentinelDispatchBus chan<- model.Service) {
 		serviceCurrentStatusIndex:               make(map[uint64]*indexStore),
 		serviceCurrentStatusData:                make(map[uint64][]*pb.TaskResult),
 		lastStatus:                              make(map[uint64]int),
+		lastFailureReason:                       make(map[uint64]string),
 		serviceResponseDataStoreCurrentUp:       make(map[uint64]uint64),
 		serviceResponseDataStoreCurrentDown:     make(map[uint64]uint64),
 		serviceResponseDataStoreCurrentAvgDelay: make(map[uint64]float32),
 		serviceResponsePing:                     make(map[uint64]map[uint64]*pingStore),
 		Services:                                make(map[uint64]*model.Service),
 		tlsCertCache:                            make(map[uint64]string),
+		tlsCertInfo:                             make(map[uint64]*model.TLSCertInfo),
+		serviceLocationStatus:                   make(map[uint64]map[uint64]*model.ServiceLocationStatus),
+		latencySamples:                          make(map[uint64]map[uint64][]model.LatencySample),
+		openIncidents:                           make(map[uint64]*model.ServiceIncident),
+		heartbeatLastPing:                       make(map[uint64]time.Time),
 		// 30天数据缓存
 		monthlyStatus: make(map[uint64]*serviceResponseItem),
 		dispatchBus:   serviceSentinelDispatchBus,
@@ -87,6 +95,12 @@ func NewServiceSentinel(serviceSentinelDispatchBus chan<- model.Service) {
 	if err != nil {
 		panic(err)
 	}
+
+	// 每日汇总 SLA 统计，并补全缺失的每日统计
+	if _, err := Cron.AddFunc("0 5 0 * * *", aggregateYesterdayServiceStats); err != nil {
+		panic(err)
+	}
+	go backfillServiceDailyStats()
 }
 
 /*
@@ -110,7 +124,12 @@ type ServiceSentinel struct {
 	serviceResponseDataStoreCurrentAvgDelay map[uint64]float32               // [service_id] -> 当前服务离线计数
 	serviceResponsePing                     map[uint64]map[uint64]*pingStore // [service_id] -> ClientID -> delay
 	lastStatus                              map[uint64]int
+	lastFailureReason                       map[uint64]string // [service_id] -> 当前统计周期内最近一次失败原因
 	tlsCertCache                            map[uint64]string
+	tlsCertInfo                             map[uint64]*model.TLSCertInfo                      // [service_id] -> TLS 证书监控最近一次获取到的证书信息
+	serviceLocationStatus                   map[uint64]map[uint64]*model.ServiceLocationStatus // [service_id] -> ServerID -> 该监测点最近一次的监控结果
+	latencySamples                          map[uint64]map[uint64][]model.LatencySample        // [service_id] -> ServerID -> 最近的延迟样本
+	openIncidents                           map[uint64]*model.ServiceIncident                  // [service_id] -> 持续中的故障
 
 	ServicesLock    sync.RWMutex
 	ServiceListLock sync.RWMutex
@@ -120,6 +139,10 @@ type ServiceSentinel struct {
 	// 30天数据缓存
 	monthlyStatusLock sync.Mutex
 	monthlyStatus     map[uint64]*serviceResponseItem
+
+	// 心跳监控最近一次收到上报的时间
+	heartbeatLock     sync.Mutex
+	heartbeatLastPing map[uint64]time.Time
 }
 
 type indexStore struct {
@@ -127,9 +150,9 @@ type indexStore struct {
 	t     time.Time
 }
 
+// pingStore 一个平均周期内的 ping 结果
 type pingStore struct {
-	count int
-	ping  float32
+	samples []model.LatencySample
 }
 
 func (ss *ServiceSentinel) refreshMonthlyServiceStatus() {
@@ -202,9 +225,7 @@ func (ss *ServiceSentinel) loadServiceHistory() {
 	for i := 0; i < len(services); i++ {
 		task := *services[i]
 		// 通过cron定时将服务监控任务传递给任务调度管道
-		services[i].CronJobID, err = Cron.AddFunc(task.CronSpec(), func() {
-			ss.dispatchBus <- task
-		})
+		services[i].CronJobID, err = Cron.AddFunc(task.CronSpec(), ss.serviceCronJob(task))
 		if err != nil {
 			panic(err)
 		}
@@ -214,6 +235,13 @@ func (ss *ServiceSentinel) loadServiceHistory() {
 	}
 	ss.ServiceList = services
 
+	// 加载持续中的故障
+	var incidents []*model.ServiceIncident
+	DB.Where("ended_at IS NULL").Find(&incidents)
+	for _, incident := range incidents {
+		ss.openIncidents[incident.ServiceID] = incident
+	}
+
 	year, month, day := time.Now().Date()
 	today := time.Date(year, month, day, 0, 0, 0, 0, Loc)
 
@@ -256,9 +284,7 @@ func (ss *ServiceSentinel) OnServiceUpdate(m model.Service) error {
 
 	var err error
 	// 写入新任务
-	m.CronJobID, err = Cron.AddFunc(m.CronSpec(), func() {
-		ss.dispatchBus <- m
-	})
+	m.CronJobID, err = Cron.AddFunc(m.CronSpec(), ss.serviceCronJob(m))
 	if err != nil {
 		return err
 	}
@@ -278,6 +304,12 @@ func (ss *ServiceSentinel) OnServiceUpdate(m model.Service) error {
 		ss.serviceCurrentStatusData[m.ID] = make([]*pb.TaskResult, _CurrentStatusSize)
 		ss.serviceStatusToday[m.ID] = &_TodayStatsOfService{}
 	}
+	// 下发规则变更后，移除不再执行该任务的监测点
+	for serverID := range ss.serviceLocationStatus[m.ID] {
+		if !m.CanDispatchTo(serverID) {
+			delete(ss.serviceLocationStatus[m.ID], serverID)
+		}
+	}
 	// 更新这个任务
 	ss.Services[m.ID] = &m
 	return nil
@@ -295,12 +327,21 @@ func (ss *ServiceSentinel) OnServiceDelete(ids []uint64) {
 		delete(ss.serviceCurrentStatusIndex, id)
 		delete(ss.serviceCurrentStatusData, id)
 		delete(ss.lastStatus, id)
+		delete(ss.lastFailureReason, id)
 		delete(ss.serviceResponseDataStoreCurrentUp, id)
 		delete(ss.serviceResponseDataStoreCurrentDown, id)
 		delete(ss.serviceResponseDataStoreCurrentAvgDelay, id)
 		delete(ss.tlsCertCache, id)
+		delete(ss.tlsCertInfo, id)
+		delete(ss.serviceLocationStatus, id)
+		delete(ss.latencySamples, id)
+		delete(ss.openIncidents, id)
 		delete(ss.serviceStatusToday, id)
 
+		ss.heartbeatLock.Lock()
+		delete(ss.heartbeatLastPing, id)
+		ss.heartbeatLock.Unlock()
+
 		// 停掉定时任务
 		Cron.Remove(ss.Services[id].CronJobID)
 		delete(ss.Services, id)
@@ -320,6 +361,14 @@ func (ss *ServiceSentinel) LoadStats() map[uint64]*serviceResponseItem {
 	// 刷新最新一天的数据
 	for k := range ss.Services {
 		ss.monthlyStatus[k].service = ss.Services[k]
+		ss.monthlyStatus[k].TLS = ss.tlsCertInfo[k]
+		ss.monthlyStatus[k].Locations = nil
+		if locations := ss.serviceLocationStatus[k]; len(locations) > 0 {
+			ss.monthlyStatus[k].Locations = make(map[uint64]model.ServiceLocationStatus, len(locations))
+			for serverID, l := range locations {
+				ss.monthlyStatus[k].Locations[serverID] = *l
+			}
+		}
 		v := ss.serviceStatusToday[k]
 
 		// 30 天在线率，
@@ -364,6 +413,42 @@ func (ss *ServiceSentinel) CopyStats() map[uint64]model.ServiceResponseItem {
 	return sri
 }
 
+// FilterServiceLocations 返回游客可见的监测点结果，去掉对游客隐藏的服务器并清空监测点上报的原始数据
+func FilterServiceLocations(locations map[uint64]model.ServiceLocationStatus) map[uint64]model.ServiceLocationStatus {
+	ServerLock.RLock()
+	defer ServerLock.RUnlock()
+
+	var filtered map[uint64]model.ServiceLocationStatus
+	for serverID, l := range locations {
+		if server, ok := ServerList[serverID]; !ok || server.HideForGuest {
+			continue
+		}
+		if filtered == nil {
+			filtered = make(map[uint64]model.ServiceLocationStatus, len(locations))
+		}
+		l.Data = ""
+		filtered[serverID] = l
+	}
+	return filtered
+}
+
+// CopyStatsOf 按给定顺序返回指定服务监控的统计信息，不要求开启 EnableShowInService
+func (ss *ServiceSentinel) CopyStatsOf(ids []uint64) []model.StatusPageService {
+	var stats map[uint64]*serviceResponseItem
+	copier.Copy(&stats, ss.LoadStats())
+
+	sri := make([]model.StatusPageService, 0, len(ids))
+	for _, id := range ids {
+		service, ok := stats[id]
+		if !ok {
+			continue
+		}
+		service.ServiceName = service.service.Name
+		sri = append(sri, model.StatusPageService{ServiceID: id, ServiceResponseItem: service.ServiceResponseItem})
+	}
+	return sri
+}
+
 // worker 服务监控的实际工作流程
 func (ss *ServiceSentinel) worker() {
 	// 从服务状态汇报管道获取汇报的服务数据
@@ -373,6 +458,25 @@ func (ss *ServiceSentinel) worker() {
 			continue
 		}
 		mh := r.Data
+		// 上报内容，TLS 监控的证书信息中仅保留校验失败原因
+		data := mh.Data
+		var certInfo *model.TLSCertInfo
+		if mh.Type == model.TaskTypeTLS {
+			ss.ServicesLock.RLock()
+			options := ss.Services[mh.GetId()].TLSOptions
+			ss.ServicesLock.RUnlock()
+			if certInfo = parseTLSCertInfo(mh.Data); certInfo != nil {
+				data = evaluateTLSCertInfo(mh, certInfo, options, time.Now())
+			}
+		}
+		if mh.Type == model.TaskTypeDNS {
+			ss.ServicesLock.RLock()
+			options := ss.Services[mh.GetId()].DNSOptions
+			ss.ServicesLock.RUnlock()
+			if d, ok := evaluateDNSResult(mh, options); ok {
+				data = d
+			}
+		}
 		if mh.Type == model.TaskTypeTCPPing || mh.Type == model.TaskTypeICMPPing {
 			serviceTcpMap, ok := ss.serviceResponsePing[mh.GetId()]
 			if !ok {
@@ -383,22 +487,27 @@ func (ss *ServiceSentinel) worker() {
 			if !ok {
 				ts = &pingStore{}
 			}
-			ts.count++
-			ts.ping = (ts.ping*float32(ts.count-1) + mh.Delay) / float32(ts.count)
-			if ts.count == Conf.AvgPingCount {
-				ts.count = 0
+			ts.samples = append(ts.samples, model.LatencySample{Delay: mh.Delay, Successful: mh.Successful})
+			if len(ts.samples) >= Conf.AvgPingCount {
+				avgDelay, loss, jitter := model.CalculatePingWindow(ts.samples)
+				ts.samples = ts.samples[:0]
 				if err := DB.Create(&model.ServiceHistory{
 					ServiceID: mh.GetId(),
-					AvgDelay:  ts.ping,
+					AvgDelay:  avgDelay,
+					Loss:      loss,
+					Jitter:    jitter,
 					Data:      mh.Data,
 					ServerID:  r.Reporter,
 				}).Error; err != nil {
 					log.Println("NEZHA>> 服务监控数据持久化失败：", err)
 				}
+				ss.checkPingQuality(mh.GetId(), r.Reporter, loss, jitter)
 			}
 			serviceTcpMap[r.Reporter] = ts
 		}
 		ss.serviceResponseDataStoreLock.Lock()
+		ss.recordLatencySample(r)
+		mh, data = ss.applyQuorum(r, data)
 		// 写入当天状态
 		if mh.Successful {
 			ss.serviceStatusToday[mh.GetId()].Delay = (ss.serviceStatusToday[mh.
@@ -407,6 +516,10 @@ func (ss *ServiceSentinel) worker() {
 			ss.serviceStatusToday[mh.GetId()].Up++
 		} else {
 			ss.serviceStatusToday[mh.GetId()].Down++
+			ss.lastFailureReason[mh.GetId()] = data
+		}
+		if certInfo != nil {
+			ss.tlsCertInfo[mh.GetId()] = certInfo
 		}
 
 		currentTime := time.Now()
@@ -453,10 +566,15 @@ func (ss *ServiceSentinel) worker() {
 				index: 0,
 				t:     currentTime,
 			}
+			// 统计周期内存在失败时记录失败原因
+			historyData := data
+			if reason, ok := ss.lastFailureReason[mh.GetId()]; ok && ss.serviceResponseDataStoreCurrentDown[mh.GetId()] > 0 {
+				historyData = reason
+			}
 			if err := DB.Create(&model.ServiceHistory{
 				ServiceID: mh.GetId(),
 				AvgDelay:  ss.serviceResponseDataStoreCurrentAvgDelay[mh.GetId()],
-				Data:      mh.Data,
+				Data:      historyData,
 				Up:        ss.serviceResponseDataStoreCurrentUp[mh.GetId()],
 				Down:      ss.serviceResponseDataStoreCurrentDown[mh.GetId()],
 			}).Error; err != nil {
@@ -473,18 +591,16 @@ func (ss *ServiceSentinel) worker() {
 				maxMuteLabel := NotificationMuteLabel.ServiceLatencyMax(mh.GetId())
 				if mh.Delay > ss.Services[mh.GetId()].MaxLatency {
 					// 延迟超过最大值
-					ServerLock.RLock()
-					reporterServer := ServerList[r.Reporter]
-					msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Services[mh.GetId()].Name, mh.Delay, ss.Services[mh.GetId()].MaxLatency, reporterServer.Name)
+					msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Services[mh.GetId()].Name, mh.Delay, ss.Services[mh.GetId()].MaxLatency, reporterName(r.Reporter))
 					go SendNotification(notificationGroupID, msg, minMuteLabel)
-					ServerLock.RUnlock()
+					if ss.Services[mh.GetId()].AutoTraceroute && r.Reporter > 0 &&
+						(mh.Type == model.TaskTypeICMPPing || mh.Type == model.TaskTypeTCPPing) {
+						go autoTraceroute(mh.GetId(), ss.Services[mh.GetId()].TracerouteTarget(), r.Reporter)
+					}
 				} else if mh.Delay < ss.Services[mh.GetId()].MinLatency {
 					// 延迟低于最小值
-					ServerLock.RLock()
-					reporterServer := ServerList[r.Reporter]
-					msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Services[mh.GetId()].Name, mh.Delay, ss.Services[mh.GetId()].MinLatency, reporterServer.Name)
+					msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Services[mh.GetId()].Name, mh.Delay, ss.Services[mh.GetId()].MinLatency, reporterName(r.Reporter))
 					go SendNotification(notificationGroupID, msg, maxMuteLabel)
-					ServerLock.RUnlock()
 				} else {
 					// 正常延迟， 清除静音缓存
 					UnMuteNotification(notificationGroupID, minMuteLabel)
@@ -501,14 +617,23 @@ func (ss *ServiceSentinel) worker() {
 			// 存储新的状态值
 			ss.lastStatus[mh.GetId()] = stateCode
 
+			// 记录故障的开始与结束，用于 SLA 统计
+			if stateCode == StatusDown {
+				ss.openIncident(mh.GetId(), data)
+			} else {
+				ss.closeIncident(mh.GetId())
+			}
+
 			// 判断是否需要发送通知
 			isNeedSendNotification := ss.Services[mh.GetId()].Notify && (lastStatus != 0 || stateCode == StatusDown)
 			if isNeedSendNotification {
-				ServerLock.RLock()
-
-				reporterServer := ServerList[r.Reporter]
 				notificationGroupID := ss.Services[mh.GetId()].NotificationGroupID
-				notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Services[mh.GetId()].Name, reporterServer.Name, mh.Data)
+				// 状态异常但本次上报成功时，展示最近一次失败原因
+				errMsg := data
+				if reason, ok := ss.lastFailureReason[mh.GetId()]; ok && mh.Successful && stateCode != StatusGood {
+					errMsg = reason
+				}
+				notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Services[mh.GetId()].Name, reporterName(r.Reporter), errMsg)
 				muteLabel := NotificationMuteLabel.ServiceStateChanged(mh.GetId())
 
 				// 状态变更时，清除静音缓存
@@ -517,103 +642,318 @@ func (ss *ServiceSentinel) worker() {
 				}
 
 				go SendNotification(notificationGroupID, notificationMsg, muteLabel)
-				ServerLock.RUnlock()
 			}
 
 			// 判断是否需要触发任务
 			isNeedTriggerTask := ss.Services[mh.GetId()].EnableTriggerTask && lastStatus != 0
 			if isNeedTriggerTask {
-				ServerLock.RLock()
-				reporterServer := ServerList[r.Reporter]
-				ServerLock.RUnlock()
-
 				if stateCode == StatusGood && lastStatus != stateCode {
 					// 当前状态正常 前序状态非正常时 触发恢复任务
-					go SendTriggerTasks(ss.Services[mh.GetId()].RecoverTriggerTasks, reporterServer.ID)
+					go SendTriggerTasks(ss.Services[mh.GetId()].RecoverTriggerTasks, r.Reporter)
 				} else if lastStatus == StatusGood && lastStatus != stateCode {
 					// 前序状态正常 当前状态非正常时 触发失败任务
-					go SendTriggerTasks(ss.Services[mh.GetId()].FailTriggerTasks, reporterServer.ID)
+					go SendTriggerTasks(ss.Services[mh.GetId()].FailTriggerTasks, r.Reporter)
 				}
 			}
 
 			ss.ServicesLock.Unlock()
 		}
-		ss.serviceResponseDataStoreLock.Unlock()
 
-		// TLS 证书报警
-		var errMsg string
-		if strings.HasPrefix(mh.Data, "SSL证书错误：") {
-			// i/o timeout、connection timeout、EOF 错误
-			if !strings.HasSuffix(mh.Data, "timeout") &&
-				!strings.HasSuffix(mh.Data, "EOF") &&
-				!strings.HasSuffix(mh.Data, "timed out") {
-				errMsg = mh.Data
-				ss.ServicesLock.RLock()
-				if ss.Services[mh.GetId()].Notify {
-					muteLabel := NotificationMuteLabel.ServiceTLS(mh.GetId(), "network")
-					go SendNotification(ss.Services[mh.GetId()].NotificationGroupID, Localizer.Tf("[TLS] Fetch cert info failed, Reporter: %s, Error: %s", ss.Services[mh.GetId()].Name, errMsg), muteLabel)
-				}
-				ss.ServicesLock.RUnlock()
+		// 上报成功且当前统计周期内已无失败时，清除失败原因
+		if mh.Successful && ss.serviceResponseDataStoreCurrentDown[mh.GetId()] == 0 {
+			delete(ss.lastFailureReason, mh.GetId())
+		}
+		ss.serviceResponseDataStoreLock.Unlock()
 
+		// TLS 证书报警，HTTP 监控与 TLS 证书监控会上报证书信息
+		switch mh.Type {
+		case model.TaskTypeHTTPGet:
+			ss.checkHTTPCert(r.Data)
+		case model.TaskTypeTLS:
+			if certInfo != nil {
+				ss.checkCert(mh.GetId(), certInfo.Issuer, certInfo.NotAfter)
 			}
+		}
+	}
+}
+
+// checkPingQuality 检查一个平均周期内的丢包率与延迟抖动是否超过阈值
+func (ss *ServiceSentinel) checkPingQuality(serviceID, reporter uint64, loss, jitter float32) {
+	ss.ServicesLock.RLock()
+	defer ss.ServicesLock.RUnlock()
+
+	service := ss.Services[serviceID]
+	if service == nil || !service.LatencyNotify {
+		return
+	}
+	notificationGroupID := service.NotificationGroupID
+	lossMuteLabel := NotificationMuteLabel.ServiceLoss(serviceID)
+	jitterMuteLabel := NotificationMuteLabel.ServiceJitter(serviceID)
+
+	if service.MaxLoss > 0 {
+		if loss > service.MaxLoss {
+			msg := Localizer.Tf("[Loss] %s %.2f%% > %.2f%%, Reporter: %s", service.Name, loss, service.MaxLoss, reporterName(reporter))
+			go SendNotification(notificationGroupID, msg, lossMuteLabel)
 		} else {
-			// 清除网络错误静音缓存
-			UnMuteNotification(ss.Services[mh.GetId()].NotificationGroupID, NotificationMuteLabel.ServiceTLS(mh.GetId(), "network"))
+			UnMuteNotification(notificationGroupID, lossMuteLabel)
+		}
+	}
+	if service.MaxJitter > 0 {
+		if jitter > service.MaxJitter {
+			msg := Localizer.Tf("[Jitter] %s %.2f > %.2f, Reporter: %s", service.Name, jitter, service.MaxJitter, reporterName(reporter))
+			go SendNotification(notificationGroupID, msg, jitterMuteLabel)
+		} else {
+			UnMuteNotification(notificationGroupID, jitterMuteLabel)
+		}
+	}
+}
+
+// recordLatencySample 记录上报服务器的延迟样本，仅保留最近 _LatencySampleSize 个
+// 调用方需持有 serviceResponseDataStoreLock
+func (ss *ServiceSentinel) recordLatencySample(r ReportData) {
+	if r.Reporter == 0 {
+		return
+	}
+	samples, ok := ss.latencySamples[r.Data.GetId()]
+	if !ok {
+		samples = make(map[uint64][]model.LatencySample)
+		ss.latencySamples[r.Data.GetId()] = samples
+	}
+	s := append(samples[r.Reporter], model.LatencySample{
+		Delay:      r.Data.Delay,
+		Successful: r.Data.Successful,
+		CreatedAt:  time.Now(),
+	})
+	if len(s) > _LatencySampleSize {
+		s = s[len(s)-_LatencySampleSize:]
+	}
+	samples[r.Reporter] = s
+}
 
-			var newCert = strings.Split(mh.Data, "|")
-			if len(newCert) > 1 {
-				ss.ServicesLock.Lock()
-				enableNotify := ss.Services[mh.GetId()].Notify
+// LatencyMatrix 返回每个服务监控在各上报服务器上的延迟统计，按服务监控与服务器 ID 排序
+func (ss *ServiceSentinel) LatencyMatrix() []*model.ServiceLatencyStats {
+	ss.serviceResponseDataStoreLock.RLock()
+	defer ss.serviceResponseDataStoreLock.RUnlock()
+	ss.ServicesLock.RLock()
+	defer ss.ServicesLock.RUnlock()
 
-				// 首次获取证书信息时，缓存证书信息
-				if ss.tlsCertCache[mh.GetId()] == "" {
-					ss.tlsCertCache[mh.GetId()] = mh.Data
-				}
+	var matrix []*model.ServiceLatencyStats
+	for serviceID, servers := range ss.latencySamples {
+		service, ok := ss.Services[serviceID]
+		if !ok {
+			continue
+		}
+		for serverID, samples := range servers {
+			stats := model.CalculateLatencyStats(samples)
+			stats.ServiceID = serviceID
+			stats.ServiceName = service.Name
+			stats.ServerID = serverID
+			stats.ServerName = reporterName(serverID)
+			matrix = append(matrix, &stats)
+		}
+	}
 
-				oldCert := strings.Split(ss.tlsCertCache[mh.GetId()], "|")
-				isCertChanged := false
-				expiresOld, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", oldCert[1])
-				expiresNew, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", newCert[1])
+	slices.SortFunc(matrix, func(a, b *model.ServiceLatencyStats) int {
+		if c := cmp.Compare(a.ServiceID, b.ServiceID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ServerID, b.ServerID)
+	})
+	return matrix
+}
 
-				// 证书变更时，更新缓存
-				if oldCert[0] != newCert[0] && !expiresNew.Equal(expiresOld) {
-					isCertChanged = true
-					ss.tlsCertCache[mh.GetId()] = mh.Data
-				}
+// applyQuorum 记录监测点的监控结果，开启多监测点共识判定时返回按共识判定后的结果与失败原因
+// 调用方需持有 serviceResponseDataStoreLock
+func (ss *ServiceSentinel) applyQuorum(r ReportData, data string) (*pb.TaskResult, string) {
+	mh := r.Data
+	now := time.Now()
 
-				notificationGroupID := ss.Services[mh.GetId()].NotificationGroupID
-				serviceName := ss.Services[mh.GetId()].Name
-				ss.ServicesLock.Unlock()
-
-				// 需要发送提醒
-				if enableNotify {
-					// 证书过期提醒
-					if expiresNew.Before(time.Now().AddDate(0, 0, 7)) {
-						expiresTimeStr := expiresNew.Format("2006-01-02 15:04:05")
-						errMsg = Localizer.Tf(
-							"The TLS certificate will expire within seven days. Expiration time: %s",
-							expiresTimeStr,
-						)
-
-						// 静音规则： 服务id+证书过期时间
-						// 用于避免多个监测点对相同证书同时报警
-						muteLabel := NotificationMuteLabel.ServiceTLS(mh.GetId(), fmt.Sprintf("expire_%s", expiresTimeStr))
-						go SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), muteLabel)
-					}
+	locations, ok := ss.serviceLocationStatus[mh.GetId()]
+	if !ok {
+		locations = make(map[uint64]*model.ServiceLocationStatus)
+		ss.serviceLocationStatus[mh.GetId()] = locations
+	}
+	// 心跳监控等没有上报服务器的结果不记录监测点
+	if r.Reporter > 0 {
+		locations[r.Reporter] = &model.ServiceLocationStatus{
+			ServerName: reporterName(r.Reporter),
+			Successful: mh.Successful,
+			Delay:      mh.Delay,
+			Data:       data,
+			UpdatedAt:  now,
+		}
+	}
 
-					// 证书变更提醒
-					if isCertChanged {
-						errMsg = Localizer.Tf(
-							"TLS certificate changed, old: issuer %s, expires at %s; new: issuer %s, expires at %s",
-							oldCert[0], expiresOld.Format("2006-01-02 15:04:05"), newCert[0], expiresNew.Format("2006-01-02 15:04:05"))
+	ss.ServicesLock.RLock()
+	service := ss.Services[mh.GetId()]
+	if service == nil || !service.QuorumEnabled() {
+		ss.ServicesLock.RUnlock()
+		return mh, data
+	}
+	failed, total := service.EvaluateQuorum(locations, now)
+	down := service.QuorumDown(len(failed))
+	ss.ServicesLock.RUnlock()
+
+	result := &pb.TaskResult{
+		Id:         mh.GetId(),
+		Type:       mh.GetType(),
+		Data:       mh.GetData(),
+		Successful: !down,
+	}
+	// 延迟取有效期内成功监测点的平均值
+	var upCount float32
+	for _, l := range locations {
+		if l.Successful && now.Sub(l.UpdatedAt) <= service.LocationResultTTL() {
+			upCount++
+			result.Delay = (result.Delay*(upCount-1) + l.Delay) / upCount
+		}
+	}
+	if !down {
+		return result, data
+	}
 
-						// 证书变更后会自动更新缓存，所以不需要静音
-						go SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), nil)
-					}
-				}
+	names := make([]string, 0, len(failed))
+	for _, id := range failed {
+		names = append(names, fmt.Sprintf("%s: %s", locations[id].ServerName, locations[id].Data))
+	}
+	data = Localizer.Tf("%d/%d locations failed: %s", len(failed), total, strings.Join(names, "; "))
+	result.Data = data
+	return result, data
+}
+
+// parseTLSCertInfo 解析 TLS 监控上报的证书信息，握手失败时上报内容为错误信息，返回 nil
+func parseTLSCertInfo(data string) *model.TLSCertInfo {
+	var info model.TLSCertInfo
+	if err := utils.Json.Unmarshal([]byte(data), &info); err != nil {
+		return nil
+	}
+	return &info
+}
+
+// evaluateDNSResult 按 DNS 监控配置判定 Agent 上报的查询结果，未通过时将上报标记为失败，
+// 返回用于记录的内容；上报内容不是查询结果（查询失败）时返回 false，沿用 Agent 的判定
+func evaluateDNSResult(mh *pb.TaskResult, options *model.ServiceDNSOptions) (string, bool) {
+	if !mh.Successful {
+		return "", false
+	}
+	var result model.TaskDNSResult
+	if err := utils.Json.Unmarshal([]byte(mh.Data), &result); err != nil {
+		return "", false
+	}
+	if err := options.Evaluate(&result); err != nil {
+		mh.Successful = false
+		return err.Error(), true
+	}
+	return strings.Join(result.Answers, ","), true
+}
+
+// evaluateTLSCertInfo 按 TLS 监控配置判定 Agent 上报的证书信息，未通过时将上报标记为失败，返回失败原因
+func evaluateTLSCertInfo(mh *pb.TaskResult, info *model.TLSCertInfo, options *model.ServiceTLSOptions, now time.Time) string {
+	if err := options.Evaluate(info, now); err != nil {
+		mh.Successful = false
+		return err.Error()
+	}
+	return ""
+}
+
+// checkHTTPCert 处理 HTTP 监控上报的证书信息，格式为 "颁发者|过期时间"
+func (ss *ServiceSentinel) checkHTTPCert(mh *pb.TaskResult) {
+	if strings.HasPrefix(mh.Data, "SSL证书错误：") {
+		// i/o timeout、connection timeout、EOF 错误
+		if !strings.HasSuffix(mh.Data, "timeout") &&
+			!strings.HasSuffix(mh.Data, "EOF") &&
+			!strings.HasSuffix(mh.Data, "timed out") {
+			ss.ServicesLock.RLock()
+			if ss.Services[mh.GetId()].Notify {
+				muteLabel := NotificationMuteLabel.ServiceTLS(mh.GetId(), "network")
+				go SendNotification(ss.Services[mh.GetId()].NotificationGroupID, Localizer.Tf("[TLS] Fetch cert info failed, Reporter: %s, Error: %s", ss.Services[mh.GetId()].Name, mh.Data), muteLabel)
 			}
+			ss.ServicesLock.RUnlock()
 		}
+		return
+	}
+
+	// 清除网络错误静音缓存
+	ss.ServicesLock.RLock()
+	UnMuteNotification(ss.Services[mh.GetId()].NotificationGroupID, NotificationMuteLabel.ServiceTLS(mh.GetId(), "network"))
+	ss.ServicesLock.RUnlock()
+
+	var newCert = strings.Split(mh.Data, "|")
+	if len(newCert) > 1 {
+		expires, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", newCert[1])
+		ss.checkCert(mh.GetId(), newCert[0], expires)
+	}
+}
+
+// checkCert 根据服务配置的提醒天数发送证书过期提醒，并在证书变更时发送提醒
+func (ss *ServiceSentinel) checkCert(serviceID uint64, issuer string, expiresNew time.Time) {
+	ss.ServicesLock.Lock()
+	service := ss.Services[serviceID]
+	if service == nil {
+		ss.ServicesLock.Unlock()
+		return
+	}
+	enableNotify := service.Notify
+
+	// 首次获取证书信息时，缓存证书信息
+	newCert := fmt.Sprintf("%s|%s", issuer, expiresNew.Format("2006-01-02 15:04:05 -0700 MST"))
+	if ss.tlsCertCache[serviceID] == "" {
+		ss.tlsCertCache[serviceID] = newCert
+	}
+
+	oldCert := strings.Split(ss.tlsCertCache[serviceID], "|")
+	isCertChanged := false
+	expiresOld, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", oldCert[1])
+
+	// 证书变更时，更新缓存
+	if oldCert[0] != issuer && !expiresNew.Equal(expiresOld) {
+		isCertChanged = true
+		ss.tlsCertCache[serviceID] = newCert
+	}
+
+	notificationGroupID := service.NotificationGroupID
+	serviceName := service.Name
+	threshold, reached := service.ReachedTLSExpiryThreshold(expiresNew)
+	ss.ServicesLock.Unlock()
+
+	// 需要发送提醒
+	if !enableNotify {
+		return
+	}
+
+	// 证书过期提醒
+	if reached {
+		expiresTimeStr := expiresNew.Format("2006-01-02 15:04:05")
+		errMsg := Localizer.Tf(
+			"The TLS certificate will expire within %d days. Expiration time: %s",
+			threshold, expiresTimeStr,
+		)
+
+		// 静音规则： 服务id+提醒天数+证书过期时间
+		// 用于避免多个监测点对相同证书同时报警，到达更小的提醒天数时再次提醒
+		muteLabel := NotificationMuteLabel.ServiceTLS(serviceID, fmt.Sprintf("expire_%d_%s", threshold, expiresTimeStr))
+		go SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), muteLabel)
+	}
+
+	// 证书变更提醒
+	if isCertChanged {
+		errMsg := Localizer.Tf(
+			"TLS certificate changed, old: issuer %s, expires at %s; new: issuer %s, expires at %s",
+			oldCert[0], expiresOld.Format("2006-01-02 15:04:05"), issuer, expiresNew.Format("2006-01-02 15:04:05"))
+
+		// 证书变更后会自动更新缓存，所以不需要静音
+		go SendNotification(notificationGroupID, fmt.Sprintf("[TLS] %s %s", serviceName, errMsg), nil)
+	}
+}
+
+// reporterName 返回上报服务器的名称，心跳监控等没有上报服务器的结果返回空字符串
+func reporterName(id uint64) string {
+	ServerLock.RLock()
+	defer ServerLock.RUnlock()
+	if server, ok := ServerList[id]; ok {
+		return server.Name
 	}
+	return ""
 }
 
 const (
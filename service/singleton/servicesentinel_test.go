@@ -0,0 +1,55 @@
+package singleton
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+func TestEvaluateDNSResult(t *testing.T) {
+	options := &model.ServiceDNSOptions{Expected: []string{"1.1.1.1"}}
+	cases := []struct {
+		name       string
+		result     *pb.TaskResult
+		data       string
+		evaluated  bool
+		successful bool
+	}{
+		{"matched", &pb.TaskResult{Successful: true, Data: `{"rcode":"NOERROR","answers":["1.1.1.1","1.0.0.1"]}`}, "1.1.1.1,1.0.0.1", true, true},
+		{"nxdomain", &pb.TaskResult{Successful: true, Data: `{"rcode":"NXDOMAIN"}`}, "dns query failed: NXDOMAIN", true, false},
+		{"mismatch", &pb.TaskResult{Successful: true, Data: `{"rcode":"NOERROR","answers":["1.0.0.1"]}`}, "", true, false},
+		{"query error", &pb.TaskResult{Successful: false, Data: "i/o timeout"}, "", false, false},
+	}
+	for _, c := range cases {
+		data, ok := evaluateDNSResult(c.result, options)
+		if ok != c.evaluated || c.result.Successful != c.successful {
+			t.Fatalf("%s: expected evaluated %v successful %v, but got %v %v", c.name, c.evaluated, c.successful, ok, c.result.Successful)
+		}
+		if c.data != "" && data != c.data {
+			t.Fatalf("%s: expected %q, but got %q", c.name, c.data, data)
+		}
+	}
+}
+
+func TestEvaluateTLSCertInfo(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name       string
+		info       model.TLSCertInfo
+		data       string
+		successful bool
+	}{
+		{"valid", model.TLSCertInfo{ChainValid: true, HostnameMatch: true, NotAfter: now.AddDate(0, 1, 0)}, "", true},
+		{"chain invalid", model.TLSCertInfo{HostnameMatch: true, Error: "x509: certificate signed by unknown authority"}, "x509: certificate signed by unknown authority", false},
+		{"hostname mismatch", model.TLSCertInfo{ChainValid: true}, "certificate does not match the server name", false},
+	}
+	for _, c := range cases {
+		mh := &pb.TaskResult{Type: model.TaskTypeTLS, Successful: true}
+		data := evaluateTLSCertInfo(mh, &c.info, nil, now)
+		if mh.Successful != c.successful || data != c.data {
+			t.Fatalf("%s: expected %v %q, but got %v %q", c.name, c.successful, c.data, mh.Successful, data)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package singleton
 
 import (
 	_ "embed"
+	"fmt"
 	"log"
 	"time"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/patrickmn/go-cache"
 	"gopkg.in/yaml.v3"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
@@ -40,12 +44,14 @@ func InitTimezoneAndCache() {
 
 // LoadSingleton 加载子服务并执行
 func LoadSingleton() {
-	initI18n()          // 加载本地化服务
-	loadNotifications() // 加载通知服务
-	loadServers()       // 加载服务器列表
-	loadCronTasks()     // 加载定时任务
+	initI18n()              // 加载本地化服务
+	loadNotifications()     // 加载通知服务
+	loadServers()           // 加载服务器列表
+	loadServerCredentials() // 加载服务器独立密钥
+	loadCronTasks()         // 加载定时任务
 	initNAT()
 	initDDNS()
+	initStatusPage()
 }
 
 // InitFrontendTemplates 从内置文件中加载FrontendTemplates
@@ -65,10 +71,29 @@ func InitConfigFromPath(path string) {
 	}
 }
 
-// InitDBFromPath 从给出的文件路径中加载数据库
+// InitDBFromPath 按配置的数据库类型连接数据库并迁移表结构，path 为 sqlite 数据库文件路径
 func InitDBFromPath(path string) {
-	var err error
-	DB, err = gorm.Open(sqlite.Open(path), &gorm.Config{
+	OpenDBFromPath(path)
+	err := DB.AutoMigrate(model.Server{}, model.User{}, model.ServerGroup{}, model.NotificationGroup{},
+		model.Notification{}, model.AlertRule{}, model.Service{}, model.NotificationGroupNotification{},
+		model.ServiceHistory{}, model.Cron{}, model.Transfer{}, model.ServerGroupServer{}, model.UserGroup{},
+		model.UserGroupUser{}, model.NAT{}, model.DDNSProfile{}, model.NotificationGroupNotification{},
+		model.WAF{}, model.Traceroute{}, model.StatusPage{}, model.StatusPageNotice{},
+		model.ServiceIncident{}, model.ServiceDailyStats{}, model.EnrollmentToken{}, model.ServerCredential{},
+		model.PendingServer{}, model.AgentCertificate{}, model.AlertEvent{}, model.UpgradeCampaign{},
+		model.UpgradeCampaignServer{})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// OpenDBFromPath 按配置的数据库类型连接数据库，不迁移表结构
+func OpenDBFromPath(path string) {
+	dialector, err := openDialector(path)
+	if err != nil {
+		panic(err)
+	}
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		CreateBatchSize: 200,
 	})
 	if err != nil {
@@ -77,14 +102,25 @@ func InitDBFromPath(path string) {
 	if Conf.Debug {
 		DB = DB.Debug()
 	}
-	err = DB.AutoMigrate(model.Server{}, model.User{}, model.ServerGroup{}, model.NotificationGroup{},
-		model.Notification{}, model.AlertRule{}, model.Service{}, model.NotificationGroupNotification{},
-		model.ServiceHistory{}, model.Cron{}, model.Transfer{}, model.ServerGroupServer{}, model.UserGroup{},
-		model.UserGroupUser{}, model.NAT{}, model.DDNSProfile{}, model.NotificationGroupNotification{},
-		model.WAF{})
-	if err != nil {
-		panic(err)
+}
+
+// openDialector 根据配置的数据库类型返回对应的驱动
+func openDialector(path string) (gorm.Dialector, error) {
+	switch Conf.DBDriver {
+	case "", model.DBDriverSQLite:
+		return sqlite.Open(path), nil
+	case model.DBDriverPostgres:
+		return postgres.Open(Conf.DSN), nil
+	case model.DBDriverMySQL:
+		cfg, err := mysqldriver.ParseDSN(Conf.DSN)
+		if err != nil {
+			return nil, err
+		}
+		// 时间字段需要解析为 time.Time
+		cfg.ParseTime = true
+		return mysql.New(mysql.Config{DSNConfig: cfg}), nil
 	}
+	return nil, fmt.Errorf("unsupported db driver: %s", Conf.DBDriver)
 }
 
 // RecordTransferHourlyUsage 对流量记录进行打点
@@ -114,55 +150,6 @@ func RecordTransferHourlyUsage() {
 	log.Println("NEZHA>> Cron 流量统计入库", len(txs), DB.Create(txs).Error)
 }
 
-// CleanServiceHistory 清理无效或过时的 监控记录 和 流量记录
-func CleanServiceHistory() {
-	// 清理已被删除的服务器的监控记录与流量记录
-	DB.Unscoped().Delete(&model.ServiceHistory{}, "created_at < ? OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -30))
-	// 由于网络监控记录的数据较多，并且前端仅使用了 1 天的数据
-	// 考虑到 sqlite 数据量问题，仅保留一天数据，
-	// server_id = 0 的数据会用于/service页面的可用性展示
-	DB.Unscoped().Delete(&model.ServiceHistory{}, "(created_at < ? AND server_id != 0) OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -1))
-	DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (SELECT `id` FROM servers)")
-	// 计算可清理流量记录的时长
-	var allServerKeep time.Time
-	specialServerKeep := make(map[uint64]time.Time)
-	var specialServerIDs []uint64
-	var alerts []model.AlertRule
-	DB.Find(&alerts)
-	for _, alert := range alerts {
-		for _, rule := range alert.Rules {
-			// 是不是流量记录规则
-			if !rule.IsTransferDurationRule() {
-				continue
-			}
-			dataCouldRemoveBefore := rule.GetTransferDurationStart().UTC()
-			// 判断规则影响的机器范围
-			if rule.Cover == model.RuleCoverAll {
-				// 更新全局可以清理的数据点
-				if allServerKeep.IsZero() || allServerKeep.After(dataCouldRemoveBefore) {
-					allServerKeep = dataCouldRemoveBefore
-				}
-			} else {
-				// 更新特定机器可以清理数据点
-				for id := range rule.Ignore {
-					if specialServerKeep[id].IsZero() || specialServerKeep[id].After(dataCouldRemoveBefore) {
-						specialServerKeep[id] = dataCouldRemoveBefore
-						specialServerIDs = append(specialServerIDs, id)
-					}
-				}
-			}
-		}
-	}
-	for id, couldRemove := range specialServerKeep {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id = ? AND datetime(`created_at`) < datetime(?)", id, couldRemove)
-	}
-	if allServerKeep.IsZero() {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (?)", specialServerIDs)
-	} else {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (?) AND datetime(`created_at`) < datetime(?)", specialServerIDs, allServerKeep)
-	}
-}
-
 // IPDesensitize 根据设置选择是否对IP进行打码处理 返回处理后的IP(关闭打码则返回原IP)
 func IPDesensitize(ip string) string {
 	if Conf.EnablePlainIPInNotification {
@@ -0,0 +1,141 @@
+package singleton
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// 设置 NZ_TEST_POSTGRES_DSN 或 NZ_TEST_MYSQL_DSN 后会同时测试本地启动的 postgres 与 mysql
+// 例如 NZ_TEST_POSTGRES_DSN="host=127.0.0.1 user=nezha password=nezha dbname=nezha_test sslmode=disable"
+// 或 NZ_TEST_MYSQL_DSN="nezha:nezha@tcp(127.0.0.1:3306)/nezha_test"，测试会清空其中的数据
+func TestDBDrivers(t *testing.T) {
+	drivers := map[string]string{
+		model.DBDriverSQLite:   "",
+		model.DBDriverPostgres: os.Getenv("NZ_TEST_POSTGRES_DSN"),
+		model.DBDriverMySQL:    os.Getenv("NZ_TEST_MYSQL_DSN"),
+	}
+	for driver, dsn := range drivers {
+		t.Run(driver, func(t *testing.T) {
+			if driver != model.DBDriverSQLite && dsn == "" {
+				t.Skip("dsn not set")
+			}
+			Conf = &model.Config{DBDriver: driver, DSN: dsn}
+			Conf.Retention.FillDefaults()
+			path := filepath.Join(t.TempDir(), "sqlite.db")
+			InitDBFromPath(path)
+			// 重复迁移不应出错
+			InitDBFromPath(path)
+			testDBQueries(t)
+		})
+	}
+}
+
+func testDBQueries(t *testing.T) {
+	for _, m := range []any{&model.Transfer{}, &model.Server{}, &model.AlertRule{}, &model.WAF{}, &model.Notification{}} {
+		if err := DB.Unscoped().Where("1 = 1").Delete(m).Error; err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+	}
+
+	server := model.Server{Name: "test"}
+	if err := DB.Create(&server).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	now := time.Now()
+	transfers := []model.Transfer{
+		{ServerID: server.ID, In: 1, Out: 10},
+		{ServerID: server.ID, In: 2, Out: 20},
+		{ServerID: server.ID, In: 4, Out: 40},
+	}
+	transfers[0].CreatedAt = now.AddDate(0, 0, -40)
+	transfers[1].CreatedAt = now.Add(-time.Hour)
+	transfers[2].CreatedAt = now
+	if err := DB.Create(&transfers).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	var count int64
+	if err := DB.Model(&model.Transfer{}).Where(model.TimeCondition(DB, "created_at", ">="), now.AddDate(0, 0, -1).UTC()).
+		Count(&count).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2, but got %d", count)
+	}
+
+	// 流量周期统计
+	cycleStart := now.Add(-2 * time.Hour)
+	rule := model.Rule{Type: "transfer_all_cycle", CycleInterval: 1, CycleUnit: "day", CycleStart: &cycleStart}
+	stats := &model.CycleTransferStats{ServerName: map[uint64]string{}, Transfer: map[uint64]uint64{}, NextUpdate: map[uint64]time.Time{}}
+	server.State = &model.HostState{}
+	rule.Snapshot(stats, &server, DB)
+	if stats.Transfer[server.ID] != 66 {
+		t.Fatalf("Expected 66, but got %d", stats.Transfer[server.ID])
+	}
+
+	// 未配置保留天数且没有流量报警规则时清理全部流量记录
+	Conf.Retention.TransferDays = 0
+	if rows := dryRunRows(t, model.RetentionClassTransfer); rows != 3 {
+		t.Fatalf("Expected 3, but got %d", rows)
+	}
+
+	// 流量报警规则统计周期早于保留天数时，保留整个周期内的记录
+	Conf.Retention.TransferDays = 30
+	ruleStart := now.AddDate(0, 0, -45)
+	alert := model.AlertRule{Name: "transfer", Rules: []*model.Rule{{Type: "transfer_all_cycle", Cover: model.RuleCoverAll,
+		CycleInterval: 2, CycleUnit: "month", CycleStart: &ruleStart}}}
+	if err := DB.Create(&alert).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if rows := dryRunRows(t, model.RetentionClassTransfer); rows != 0 {
+		t.Fatalf("Expected 0, but got %d", rows)
+	}
+	if err := DB.Delete(&alert).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	// 保留 30 天流量记录
+	if rows := dryRunRows(t, model.RetentionClassTransfer); rows != 1 {
+		t.Fatalf("Expected 1, but got %d", rows)
+	}
+	CleanServiceHistory()
+	if err := DB.Model(&model.Transfer{}).Count(&count).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2, but got %d", count)
+	}
+
+	if err := model.BlockIP(DB, "10.0.0.1", model.WAFBlockReasonTypeLoginFail); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := model.BlockIP(DB, "10.0.0.1", model.WAFBlockReasonTypeLoginFail); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := model.CheckIP(DB, "10.0.0.1"); err == nil {
+		t.Fatalf("Expected blocked, but passed")
+	}
+
+	n := model.Notification{Name: "test", URL: "https://example.com", RequestBody: model.LongText(make([]byte, 70000))}
+	if err := DB.Create(&n).Error; err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+}
+
+func dryRunRows(t *testing.T, class string) int64 {
+	items, err := DryRunRetention()
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	var rows int64
+	for _, item := range items {
+		if item.Class == class {
+			rows += item.Rows
+		}
+	}
+	return rows
+}
@@ -0,0 +1,145 @@
+package singleton
+
+import (
+	"log"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+// openIncident 服务状态变为故障时记录故障开始
+// 调用方需持有 serviceResponseDataStoreLock
+func (ss *ServiceSentinel) openIncident(serviceID uint64, reason string) {
+	if _, ok := ss.openIncidents[serviceID]; ok {
+		return
+	}
+	incident := &model.ServiceIncident{
+		ServiceID: serviceID,
+		StartedAt: time.Now(),
+		Reason:    reason,
+	}
+	if err := DB.Create(incident).Error; err != nil {
+		log.Println("NEZHA>> 服务故障记录持久化失败：", err)
+		return
+	}
+	ss.openIncidents[serviceID] = incident
+}
+
+// closeIncident 服务状态恢复时记录故障结束
+// 调用方需持有 serviceResponseDataStoreLock
+func (ss *ServiceSentinel) closeIncident(serviceID uint64) {
+	incident, ok := ss.openIncidents[serviceID]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	incident.EndedAt = &now
+	if err := DB.Save(incident).Error; err != nil {
+		log.Println("NEZHA>> 服务故障记录持久化失败：", err)
+	}
+	delete(ss.openIncidents, serviceID)
+}
+
+// AggregateServiceDailyStats 汇总指定日期的服务监控记录与故障记录，生成每日统计
+func AggregateServiceDailyStats(day time.Time) error {
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, Loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var serviceIDs []uint64
+	if err := DB.Model(&model.Service{}).Pluck("id", &serviceIDs).Error; err != nil {
+		return err
+	}
+
+	type historySum struct {
+		ServiceID uint64
+		Up        uint64
+		Down      uint64
+		AvgDelay  float32
+	}
+	var sums []historySum
+	if err := DB.Model(&model.ServiceHistory{}).
+		Select("service_id, SUM(up) AS up, SUM(down) AS down, AVG(avg_delay) AS avg_delay").
+		Where("server_id = 0 AND created_at >= ? AND created_at < ?", dayStart, dayEnd).
+		Group("service_id").Scan(&sums).Error; err != nil {
+		return err
+	}
+	var incidents []*model.ServiceIncident
+	if err := DB.Where("started_at < ? AND (ended_at IS NULL OR ended_at >= ?)", dayEnd, dayStart).
+		Find(&incidents).Error; err != nil {
+		return err
+	}
+
+	statsMap := make(map[uint64]*model.ServiceDailyStats, len(serviceIDs))
+	for _, id := range serviceIDs {
+		statsMap[id] = &model.ServiceDailyStats{ServiceID: id, Date: dayStart}
+	}
+	for _, sum := range sums {
+		if stats, ok := statsMap[sum.ServiceID]; ok {
+			stats.Up, stats.Down, stats.AvgDelay = sum.Up, sum.Down, sum.AvgDelay
+		}
+	}
+	now := time.Now()
+	for _, incident := range incidents {
+		if stats, ok := statsMap[incident.ServiceID]; ok {
+			stats.AddIncident(incident, dayStart, dayEnd, now)
+		}
+	}
+
+	stats := make([]*model.ServiceDailyStats, 0, len(statsMap))
+	for _, s := range statsMap {
+		if s.Up+s.Down > 0 || s.Downtime > 0 {
+			stats = append(stats, s)
+		}
+	}
+
+	tx := DB.Begin()
+	if err := tx.Unscoped().Delete(&model.ServiceDailyStats{}, "date = ?", dayStart).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(stats) > 0 {
+		if err := tx.Create(stats).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// aggregateYesterdayServiceStats 每日汇总前一天的服务监控统计
+func aggregateYesterdayServiceStats() {
+	if err := AggregateServiceDailyStats(time.Now().In(Loc).AddDate(0, 0, -1)); err != nil {
+		log.Println("NEZHA>> 服务监控每日统计失败：", err)
+	}
+}
+
+// backfillServiceDailyStats 补全服务监控记录保留期内缺失的每日统计
+func backfillServiceDailyStats() {
+	today := time.Now().In(Loc)
+	for i := 29; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, Loc)
+		var count int64
+		if err := DB.Model(&model.ServiceDailyStats{}).Where("date = ?", dayStart).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		if err := AggregateServiceDailyStats(day); err != nil {
+			log.Println("NEZHA>> 服务监控每日统计失败：", err)
+		}
+	}
+}
+
+// GetSLAReport 生成服务监控在统计周期内的 SLA 报表，统计截至前一天
+func GetSLAReport(service *model.Service, period string) (*model.SLAReport, error) {
+	from, to, err := model.ParseSLAPeriod(period, Loc)
+	if err != nil {
+		return nil, err
+	}
+	var days []model.ServiceDailyStats
+	if err := DB.Where("service_id = ? AND date >= ? AND date < ?", service.ID, from, to).
+		Order("date").Find(&days).Error; err != nil {
+		return nil, err
+	}
+	report := model.NewSLAReport(service, period, from, to, days)
+	return &report, nil
+}
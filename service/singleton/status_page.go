@@ -0,0 +1,99 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/nezhahq/nezha/model"
+)
+
+var (
+	StatusPageCache       = make(map[uint64]*model.StatusPage) // [status_page_id] -> StatusPage
+	StatusPageDomainCache = make(map[string]*model.StatusPage) // [domain] -> StatusPage
+	StatusPageCacheLock   sync.RWMutex
+
+	StatusPageList     []*model.StatusPage
+	StatusPageListLock sync.RWMutex
+)
+
+func initStatusPage() {
+	DB.Find(&StatusPageList)
+	StatusPageCacheLock.Lock()
+	defer StatusPageCacheLock.Unlock()
+	for _, p := range StatusPageList {
+		StatusPageCache[p.ID] = p
+		if p.Domain != "" {
+			StatusPageDomainCache[p.Domain] = p
+		}
+	}
+}
+
+func OnStatusPageUpdate(p *model.StatusPage) {
+	StatusPageCacheLock.Lock()
+	defer StatusPageCacheLock.Unlock()
+
+	if old, ok := StatusPageCache[p.ID]; ok && old.Domain != "" {
+		delete(StatusPageDomainCache, old.Domain)
+	}
+	StatusPageCache[p.ID] = p
+	if p.Domain != "" {
+		StatusPageDomainCache[p.Domain] = p
+	}
+}
+
+func OnStatusPageDelete(ids []uint64) {
+	StatusPageCacheLock.Lock()
+	defer StatusPageCacheLock.Unlock()
+
+	for _, id := range ids {
+		if p, ok := StatusPageCache[id]; ok {
+			if p.Domain != "" {
+				delete(StatusPageDomainCache, p.Domain)
+			}
+			delete(StatusPageCache, id)
+		}
+	}
+}
+
+func UpdateStatusPageList() {
+	StatusPageCacheLock.RLock()
+	defer StatusPageCacheLock.RUnlock()
+
+	StatusPageListLock.Lock()
+	defer StatusPageListLock.Unlock()
+
+	StatusPageList = make([]*model.StatusPage, 0, len(StatusPageCache))
+	for _, p := range StatusPageCache {
+		StatusPageList = append(StatusPageList, p)
+	}
+	slices.SortFunc(StatusPageList, func(a, b *model.StatusPage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// GetStatusPageByDomain 按自定义域名查找状态页
+func GetStatusPageByDomain(domain string) *model.StatusPage {
+	StatusPageCacheLock.RLock()
+	defer StatusPageCacheLock.RUnlock()
+	return StatusPageDomainCache[domain]
+}
+
+// GetStatusPageByID 按 ID 查找状态页
+func GetStatusPageByID(id uint64) *model.StatusPage {
+	StatusPageCacheLock.RLock()
+	defer StatusPageCacheLock.RUnlock()
+	return StatusPageCache[id]
+}
+
+// GetStatusPageBySlug 按路径标识查找状态页
+func GetStatusPageBySlug(slug string) *model.StatusPage {
+	StatusPageCacheLock.RLock()
+	defer StatusPageCacheLock.RUnlock()
+	for _, p := range StatusPageCache {
+		if p.Slug == slug {
+			return p
+		}
+	}
+	return nil
+}
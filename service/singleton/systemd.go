@@ -0,0 +1,58 @@
+package singleton
+
+import (
+	"log"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// PushWatchedUnits 向服务器下发监控的 systemd 单元列表，Agent 未连接时跳过，连接后会重新下发
+func PushWatchedUnits(serverID uint64) error {
+	ServerLock.RLock()
+	var stream pb.NezhaService_RequestTaskServer
+	var units []string
+	if server, ok := ServerList[serverID]; ok {
+		stream = server.TaskStream
+		units = server.WatchedUnits
+	}
+	ServerLock.RUnlock()
+	if stream == nil {
+		return nil
+	}
+
+	if units == nil {
+		units = []string{}
+	}
+	data, err := utils.Json.Marshal(&model.TaskSystemdUnits{Units: units})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.Task{
+		Type: model.TaskTypeSystemdUnits,
+		Data: string(data),
+	})
+}
+
+// OnSystemdUnitsResult 保存 Agent 上报的 systemd 单元状态
+func OnSystemdUnitsResult(serverID uint64, r *pb.TaskResult) {
+	if !r.GetSuccessful() {
+		log.Printf("NEZHA>> systemd 单元状态获取失败：%s, serverID: %d\n", r.GetData(), serverID)
+		return
+	}
+	var units []model.SystemdUnit
+	if err := utils.Json.Unmarshal([]byte(r.GetData()), &units); err != nil {
+		log.Printf("NEZHA>> systemd 单元状态解析失败：%v, serverID: %d\n", err, serverID)
+		return
+	}
+	for i := range units {
+		units[i].Name = model.NormalizeSystemdUnit(units[i].Name)
+	}
+
+	ServerLock.RLock()
+	defer ServerLock.RUnlock()
+	if server, ok := ServerList[serverID]; ok {
+		server.SystemdUnits = model.FilterSystemdUnits(units, server.WatchedUnits)
+	}
+}
@@ -0,0 +1,92 @@
+package singleton
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// RequestTraceroute 向服务器下发路由追踪任务并创建记录，结果由 Agent 异步上报
+func RequestTraceroute(serverID, serviceID uint64, task *model.TaskTraceroute, trigger string) (*model.Traceroute, error) {
+	ServerLock.RLock()
+	var stream pb.NezhaService_RequestTaskServer
+	if server, ok := ServerList[serverID]; ok {
+		stream = server.TaskStream
+	}
+	ServerLock.RUnlock()
+	if stream == nil {
+		return nil, Localizer.ErrorT("server not found or not connected")
+	}
+
+	if task.MaxHops == 0 {
+		task.MaxHops = model.DefaultTracerouteMaxHops
+	}
+	if task.Count == 0 {
+		task.Count = model.DefaultTracerouteCount
+	}
+	data, err := utils.Json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &model.Traceroute{
+		ServerID:  serverID,
+		ServiceID: serviceID,
+		Target:    task.Target,
+		Trigger:   trigger,
+	}
+	if err := DB.Create(t).Error; err != nil {
+		return nil, err
+	}
+
+	if err := stream.Send(&pb.Task{
+		Id:   t.ID,
+		Type: model.TaskTypeTraceroute,
+		Data: string(data),
+	}); err != nil {
+		t.Finished = true
+		t.Error = err.Error()
+		DB.Save(t)
+		return nil, err
+	}
+	return t, nil
+}
+
+// OnTracerouteResult 保存 Agent 上报的路由追踪结果
+func OnTracerouteResult(serverID uint64, r *pb.TaskResult) {
+	var t model.Traceroute
+	if err := DB.First(&t, "id = ? AND server_id = ?", r.GetId(), serverID).Error; err != nil {
+		log.Printf("NEZHA>> 路由追踪记录不存在，id: %d, serverID: %d\n", r.GetId(), serverID)
+		return
+	}
+
+	t.Finished = true
+	t.Successful = r.GetSuccessful()
+	if t.Successful {
+		if err := utils.Json.Unmarshal([]byte(r.GetData()), &t.Hops); err != nil {
+			t.Successful = false
+			t.Error = err.Error()
+		}
+	} else {
+		t.Error = r.GetData()
+	}
+
+	if err := DB.Save(&t).Error; err != nil {
+		log.Println("NEZHA>> 路由追踪结果持久化失败：", err)
+	}
+}
+
+// autoTraceroute ping 监控延迟超过阈值时自动发起路由追踪，同一服务监控与服务器 30 分钟内只发起一次
+func autoTraceroute(serviceID uint64, target string, reporter uint64) {
+	key := fmt.Sprintf("traceroute::%d-%d", serviceID, reporter)
+	if err := Cache.Add(key, true, 30*time.Minute); err != nil {
+		return
+	}
+	if _, err := RequestTraceroute(reporter, serviceID, &model.TaskTraceroute{Target: target}, model.TracerouteTriggerLatency); err != nil {
+		log.Printf("NEZHA>> 自动路由追踪失败：%v, serviceID: %d, serverID: %d\n", err, serviceID, reporter)
+	}
+}
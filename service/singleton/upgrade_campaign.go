@@ -0,0 +1,263 @@
+package singleton
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/nezhahq/nezha/model"
+	"github.com/nezhahq/nezha/pkg/utils"
+	pb "github.com/nezhahq/nezha/proto"
+)
+
+// upgradeCampaignLock 保证升级计划的推进与暂停、恢复、取消操作互斥
+var upgradeCampaignLock sync.Mutex
+
+// CreateUpgradeCampaign 创建升级计划并按批次划分服务器，随后立即开始第一批升级
+func CreateUpgradeCampaign(c *model.UpgradeCampaign, servers []uint64) error {
+	if c.Timeout == 0 {
+		c.Timeout = model.DefaultUpgradeTimeout
+	}
+	c.Status = model.UpgradeCampaignRunning
+
+	waves := model.PlanUpgradeWaves(servers, c.CanaryPercent, c.BatchSize)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(c).Error; err != nil {
+			return err
+		}
+		for wave, ids := range waves {
+			for _, id := range ids {
+				if err := tx.Create(&model.UpgradeCampaignServer{
+					CampaignID: c.ID,
+					ServerID:   id,
+					Wave:       wave,
+					Status:     model.UpgradeServerPending,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go ProcessUpgradeCampaigns()
+	return nil
+}
+
+// ProcessUpgradeCampaigns 推进所有进行中的升级计划，由定时任务周期调用
+func ProcessUpgradeCampaigns() {
+	upgradeCampaignLock.Lock()
+	defer upgradeCampaignLock.Unlock()
+
+	var campaigns []*model.UpgradeCampaign
+	if err := DB.Where("status = ?", model.UpgradeCampaignRunning).Find(&campaigns).Error; err != nil {
+		log.Println("NEZHA>> 升级计划加载失败：", err)
+		return
+	}
+	now := time.Now()
+	for _, c := range campaigns {
+		if err := processUpgradeCampaign(c, now); err != nil {
+			log.Printf("NEZHA>> 升级计划推进失败：%v, campaignID: %d\n", err, c.ID)
+		}
+	}
+}
+
+func processUpgradeCampaign(c *model.UpgradeCampaign, now time.Time) error {
+	var servers []*model.UpgradeCampaignServer
+	if err := DB.Where("campaign_id = ?", c.ID).Order("wave, id").Find(&servers).Error; err != nil {
+		return err
+	}
+
+	// 以 Agent 上报的版本确认已下发的服务器
+	failed := false
+	timeout := time.Duration(c.Timeout) * time.Second
+	for _, s := range servers {
+		if s.Status != model.UpgradeServerSent {
+			continue
+		}
+		version, _, ok := upgradeServerInfo(s.ServerID)
+		switch {
+		case ok && model.UpgradeVersionMatches(version, c.Version):
+			finishUpgradeServer(s, model.UpgradeServerSucceeded, "", now)
+		case s.SentAt == nil || now.Sub(*s.SentAt) > timeout:
+			finishUpgradeServer(s, model.UpgradeServerFailed, fmt.Sprintf("version not confirmed in %s, current version: %s", timeout, version), now)
+			failed = true
+		}
+	}
+	progress := model.NewUpgradeCampaignProgress(servers)
+	if progress.CurrentWave >= progress.Waves {
+		return DB.Model(c).Update("status", model.UpgradeCampaignCompleted).Error
+	}
+	if failed && c.PauseOnFailure {
+		return DB.Model(c).Update("status", model.UpgradeCampaignPaused).Error
+	}
+
+	// 当前批次中尚未下发的服务器，前一批次全部结束后才会进入下一批次
+	for _, s := range servers {
+		if s.Wave != progress.CurrentWave || s.Status != model.UpgradeServerPending {
+			continue
+		}
+		if !sendUpgradeTask(c, s, now) {
+			failed = true
+		}
+	}
+	if failed && c.PauseOnFailure {
+		return DB.Model(c).Update("status", model.UpgradeCampaignPaused).Error
+	}
+	return nil
+}
+
+// sendUpgradeTask 向服务器下发升级任务，下发失败时返回 false
+func sendUpgradeTask(c *model.UpgradeCampaign, s *model.UpgradeCampaignServer, now time.Time) bool {
+	version, stream, ok := upgradeServerInfo(s.ServerID)
+	if !ok {
+		finishUpgradeServer(s, model.UpgradeServerSkipped, "server not found", now)
+		return true
+	}
+	if model.UpgradeVersionMatches(version, c.Version) {
+		s.FromVersion = version
+		finishUpgradeServer(s, model.UpgradeServerSucceeded, "", now)
+		return true
+	}
+	if stream == nil {
+		finishUpgradeServer(s, model.UpgradeServerSkipped, "server not connected", now)
+		return true
+	}
+
+	data, err := utils.Json.Marshal(&model.TaskUpgrade{Version: c.Version})
+	if err != nil {
+		finishUpgradeServer(s, model.UpgradeServerFailed, err.Error(), now)
+		return false
+	}
+	if err := stream.Send(&pb.Task{
+		Id:   c.ID,
+		Type: model.TaskTypeUpgrade,
+		Data: string(data),
+	}); err != nil {
+		finishUpgradeServer(s, model.UpgradeServerFailed, err.Error(), now)
+		return false
+	}
+
+	s.Status = model.UpgradeServerSent
+	s.FromVersion = version
+	s.SentAt = &now
+	if err := DB.Save(s).Error; err != nil {
+		log.Println("NEZHA>> 升级状态持久化失败：", err)
+	}
+	return true
+}
+
+func upgradeServerInfo(serverID uint64) (string, pb.NezhaService_RequestTaskServer, bool) {
+	ServerLock.RLock()
+	defer ServerLock.RUnlock()
+	server, ok := ServerList[serverID]
+	if !ok {
+		return "", nil, false
+	}
+	var version string
+	if server.Host != nil {
+		version = server.Host.Version
+	}
+	return version, server.TaskStream, true
+}
+
+func finishUpgradeServer(s *model.UpgradeCampaignServer, status, errMsg string, now time.Time) {
+	s.Status = status
+	s.Error = errMsg
+	s.FinishedAt = &now
+	if err := DB.Save(s).Error; err != nil {
+		log.Println("NEZHA>> 升级状态持久化失败：", err)
+	}
+}
+
+// OnUpgradeResult 处理 Agent 上报的升级失败结果，升级成功以上报的版本确认
+func OnUpgradeResult(serverID uint64, r *pb.TaskResult) {
+	if r.GetSuccessful() || r.GetId() == 0 {
+		return
+	}
+
+	upgradeCampaignLock.Lock()
+	defer upgradeCampaignLock.Unlock()
+
+	var s model.UpgradeCampaignServer
+	if tx := DB.Where("campaign_id = ? AND server_id = ? AND status = ?", r.GetId(), serverID, model.UpgradeServerSent).
+		Limit(1).Find(&s); tx.Error != nil || tx.RowsAffected == 0 {
+		return
+	}
+	finishUpgradeServer(&s, model.UpgradeServerFailed, r.GetData(), time.Now())
+
+	if err := DB.Model(&model.UpgradeCampaign{}).
+		Where("id = ? AND status = ? AND pause_on_failure = ?", r.GetId(), model.UpgradeCampaignRunning, true).
+		Update("status", model.UpgradeCampaignPaused).Error; err != nil {
+		log.Println("NEZHA>> 升级计划暂停失败：", err)
+	}
+}
+
+// PauseUpgradeCampaigns 暂停进行中的升级计划，已下发的服务器在恢复后继续确认
+func PauseUpgradeCampaigns(ids []uint64) error {
+	upgradeCampaignLock.Lock()
+	defer upgradeCampaignLock.Unlock()
+	return DB.Model(&model.UpgradeCampaign{}).Where("id IN (?) AND status = ?", ids, model.UpgradeCampaignRunning).
+		Update("status", model.UpgradeCampaignPaused).Error
+}
+
+// ResumeUpgradeCampaigns 恢复已暂停的升级计划
+func ResumeUpgradeCampaigns(ids []uint64) error {
+	upgradeCampaignLock.Lock()
+	err := DB.Model(&model.UpgradeCampaign{}).Where("id IN (?) AND status = ?", ids, model.UpgradeCampaignPaused).
+		Update("status", model.UpgradeCampaignRunning).Error
+	upgradeCampaignLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	go ProcessUpgradeCampaigns()
+	return nil
+}
+
+// CancelUpgradeCampaigns 取消未结束的升级计划，尚未下发的服务器标记为跳过
+func CancelUpgradeCampaigns(ids []uint64) error {
+	upgradeCampaignLock.Lock()
+	defer upgradeCampaignLock.Unlock()
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var cancel []uint64
+		if err := tx.Model(&model.UpgradeCampaign{}).Where("id IN (?) AND status IN (?)", ids,
+			[]string{model.UpgradeCampaignRunning, model.UpgradeCampaignPaused}).Pluck("id", &cancel).Error; err != nil {
+			return err
+		}
+		if len(cancel) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.UpgradeCampaign{}).Where("id IN (?)", cancel).
+			Update("status", model.UpgradeCampaignCancelled).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.UpgradeCampaignServer{}).
+			Where("campaign_id IN (?) AND status = ?", cancel, model.UpgradeServerPending).
+			Updates(map[string]any{
+				"status":      model.UpgradeServerSkipped,
+				"error":       "campaign cancelled",
+				"finished_at": time.Now(),
+			}).Error
+	})
+}
+
+// DeleteUpgradeCampaigns 删除升级计划及其服务器升级记录
+func DeleteUpgradeCampaigns(ids []uint64) error {
+	upgradeCampaignLock.Lock()
+	defer upgradeCampaignLock.Unlock()
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&model.UpgradeCampaign{}, "id IN (?)", ids).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.UpgradeCampaignServer{}, "campaign_id IN (?)", ids).Error
+	})
+}